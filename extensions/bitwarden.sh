@@ -10,6 +10,16 @@ if [ $# -eq 0 ]; then
     jq -n '{
         title: "Bitwarden Vault",
         description: "Search your Bitwarden passwords",
+        requirements: [
+            {
+                name: "bw",
+                link: "https://bitwarden.com/help/cli/"
+            },
+            {
+                name: "jq",
+                link: "https://jqlang.github.io/jq/download/"
+            }
+        ],
         preferences: [
             {
                 name: "session",
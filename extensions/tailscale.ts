@@ -4,6 +4,12 @@ import type * as sunbeam from "https://deno.land/x/sunbeam/mod.ts";
 const manifest = {
   title: "Tailscale",
   description: "Manage your tailscale devices",
+  requirements: [
+    {
+      name: "tailscale",
+      link: "https://tailscale.com/download",
+    },
+  ],
   commands: [
     {
       name: "list-devices",
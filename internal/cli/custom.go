@@ -2,11 +2,15 @@ package cli
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/mattn/go-isatty"
 	"github.com/pomdtr/sunbeam/internal/config"
@@ -17,6 +21,101 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// NewCmdLazyCustom registers an extension without loading it.
+// The extension is only loaded when the command is invoked, or when its arguments are completed.
+func NewCmdLazyCustom(cfg config.Config, alias string, index *extensions.Index) *cobra.Command {
+	extensionConfig := cfg.Extensions[alias]
+
+	short := extensionConfig.Origin
+	if manifest, ok := extensions.CachedManifest(index, extensionConfig.Origin); ok {
+		short = manifest.Title
+	}
+
+	loadCmd := func() (*cobra.Command, error) {
+		extension, err := extensions.LoadExtension(cfg, alias)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load extension %s: %w", alias, err)
+		}
+
+		cmd, err := NewCmdCustom(alias, extension, extensionConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+		return cmd, nil
+	}
+
+	cmd := &cobra.Command{
+		Use:                alias,
+		Short:              short,
+		GroupID:            CommandGroupExtension,
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			extensionCmd, err := loadCmd()
+			if err != nil {
+				return err
+			}
+
+			extensionCmd.SetArgs(args)
+			extensionCmd.SetIn(cmd.InOrStdin())
+			extensionCmd.SetOut(cmd.OutOrStdout())
+			extensionCmd.SetErr(cmd.ErrOrStderr())
+			return extensionCmd.Execute()
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			extensionCmd, err := loadCmd()
+			if err != nil {
+				cobra.CompErrorln(err.Error())
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			// delegate the completion to the extension command, and parse its output
+			var stdout bytes.Buffer
+			extensionCmd.SetArgs(append([]string{cobra.ShellCompRequestCmd}, append(args, toComplete)...))
+			extensionCmd.SetOut(&stdout)
+			extensionCmd.SetErr(io.Discard)
+			if err := extensionCmd.Execute(); err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			lines := strings.Split(strings.TrimRight(stdout.String(), "\n"), "\n")
+			if len(lines) == 0 || !strings.HasPrefix(lines[len(lines)-1], ":") {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			directive, err := strconv.Atoi(strings.TrimPrefix(lines[len(lines)-1], ":"))
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+
+			var completions []string
+			for _, line := range lines[:len(lines)-1] {
+				if line == "" {
+					continue
+				}
+				completions = append(completions, line)
+			}
+
+			return completions, cobra.ShellCompDirective(directive)
+		},
+	}
+
+	cmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		extensionCmd, err := loadCmd()
+		if err != nil {
+			cmd.PrintErrln(err)
+			return
+		}
+
+		extensionCmd.SetOut(cmd.OutOrStdout())
+		_ = extensionCmd.Help()
+	})
+
+	return cmd
+}
+
 func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig config.ExtensionConfig) (*cobra.Command, error) {
 	rootCmd := &cobra.Command{
 		Use:     alias,
@@ -35,7 +134,7 @@ func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig
 			}
 
 			if len(inputBytes) == 0 {
-				if !isatty.IsTerminal(os.Stdout.Fd()) {
+				if !isInteractive() {
 					encoder := json.NewEncoder(os.Stdout)
 					encoder.SetIndent("", "  ")
 					encoder.SetEscapeHTML(false)
@@ -58,7 +157,7 @@ func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig
 						return config.Config{}, nil, err
 					}
 
-					items := extensionListItems(alias, extension, extensionConfig)
+					items := tui.ExtensionListItems(alias, extension, extensionConfig)
 					return cfg, items, nil
 				})
 
@@ -79,6 +178,8 @@ func NewCmdCustom(alias string, extension extensions.Extension, extensionConfig
 
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.SetHelpCommand(&cobra.Command{Hidden: true})
+	// flags are not parsed by the lazy command, so the global flags must be declared again
+	addGlobalFlags(rootCmd)
 
 	commands := extension.Manifest.Commands
 	sort.Slice(extension.Manifest.Commands, func(i, j int) bool {
@@ -184,8 +285,19 @@ func runExtension(extension extensions.Extension, input sunbeam.Payload) error {
 		return fmt.Errorf("command %s not found", input.Command)
 	}
 
-	if !isatty.IsTerminal(os.Stdout.Fd()) {
-		cmd, err := extension.Cmd(input)
+	if err := extension.CheckRequirements(); err != nil {
+		return err
+	}
+
+	if !isInteractive() {
+		// piped commands may stream for a long time, they are only bounded by a configured run timeout
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if extension.Timeouts.Run > 0 {
+			ctx, cancel = context.WithTimeout(ctx, extension.Timeouts.Run)
+		}
+		defer cancel()
+
+		cmd, err := extension.CmdContext(ctx, input)
 		if err != nil {
 			return err
 		}
@@ -194,7 +306,15 @@ func runExtension(extension extensions.Extension, input sunbeam.Payload) error {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 
-		return cmd.Run()
+		if err := extension.RunCmd(cmd, input.Command); err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return extensions.TimeoutError{Alias: extension.Alias, Timeout: extension.Timeouts.Run, Op: fmt.Sprintf("running command %s", input.Command)}
+			}
+
+			return err
+		}
+
+		return nil
 	}
 
 	switch command.Mode {
@@ -0,0 +1,8 @@
+#!/bin/sh
+# the extension is built on the first run, and rebuilt when its sources change
+dir=$(cd "$(dirname "$0")" && pwd) || exit 1
+bin="$dir/.build/extension"
+if [ ! -x "$bin" ] || [ -n "$(find "$dir" \( -name '*.go' -o -name go.mod -o -name go.sum \) -newer "$bin")" ]; then
+	(cd "$dir" && go build -o "$bin" .) || exit 1
+fi
+exec "$bin" "$@"
@@ -1,20 +1,32 @@
 package cli
 
 import (
+	"bufio"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/cli/go-gh/v2/pkg/tableprinter"
 	"github.com/mattn/go-isatty"
 	"github.com/pomdtr/sunbeam/internal/config"
 	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/history"
 	"github.com/pomdtr/sunbeam/internal/tui"
+	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -29,30 +41,82 @@ func NewCmdExtension(cfg config.Config) *cobra.Command {
 	}
 
 	cmd.AddCommand(NewCmdExtensionInstall(cfg))
+	cmd.AddCommand(NewCmdExtensionSearch(cfg))
+	cmd.AddCommand(NewCmdExtensionBrowse(cfg))
 	cmd.AddCommand(NewCmdExtensionUpgrade(cfg))
+	cmd.AddCommand(NewCmdExtensionOutdated(cfg))
+	cmd.AddCommand(NewCmdExtensionRollback(cfg))
 	cmd.AddCommand(NewCmdExtensionRename(cfg))
 	cmd.AddCommand(NewCmdExtensionList(cfg))
+	cmd.AddCommand(NewCmdExtensionDoctor(cfg))
 	cmd.AddCommand(NewCmdExtensionRemove(cfg))
+	cmd.AddCommand(NewCmdExtensionPrune(cfg))
 	cmd.AddCommand(NewCmdExtensionConfigure(cfg))
 	cmd.AddCommand(NewCmdExtensionEdit(cfg))
+	cmd.AddCommand(NewCmdExtensionDev(cfg))
+	cmd.AddCommand(NewCmdExtensionTest(cfg))
 	cmd.AddCommand(NewCmdExtensionCreate())
+	cmd.AddCommand(NewCmdExtensionPack())
 
 	return cmd
 }
 
 func extractAlias(origin string) (string, error) {
+	if extensions.IsGit(origin) {
+		gitOrigin, err := extensions.ParseGitOrigin(origin)
+		if err != nil {
+			return "", err
+		}
+
+		base := filepath.Base(gitOrigin.Path)
+		return strings.TrimSuffix(base, filepath.Ext(base)), nil
+	}
+
 	originUrl, err := url.Parse(origin)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse origin: %w", err)
 	}
 
 	base := filepath.Base(originUrl.Path)
+	if strings.HasSuffix(base, ".tar.gz") {
+		return strings.TrimSuffix(base, ".tar.gz"), nil
+	}
 
 	return strings.TrimSuffix(base, filepath.Ext(base)), nil
 }
 
+var aliasRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// validateAlias checks that the alias can be used as a command name, without shadowing a builtin command
+func validateAlias(cmd *cobra.Command, alias string) error {
+	if !aliasRegexp.MatchString(alias) {
+		return fmt.Errorf("invalid alias %s: only letters, digits, - and _ are allowed", alias)
+	}
+
+	// the help and completion commands are added to the root before any command runs
+	for _, builtin := range cmd.Root().Commands() {
+		if builtin.GroupID == CommandGroupExtension {
+			continue
+		}
+
+		if builtin.Name() == alias || builtin.HasAlias(alias) {
+			return fmt.Errorf("alias %s is reserved by the %s command", alias, builtin.Name())
+		}
+	}
+
+	return nil
+}
+
 func normalizeOrigin(origin string) (string, error) {
-	if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+	if extensions.IsGit(origin) {
+		if _, err := extensions.ParseGitOrigin(origin); err != nil {
+			return "", err
+		}
+
+		return origin, nil
+	}
+
+	if !extensions.IsRemote(origin) {
 		if _, err := os.Stat(origin); err != nil {
 			return "", fmt.Errorf("failed to find origin: %w", err)
 		}
@@ -81,6 +145,12 @@ var denoExtBytes []byte
 //go:embed embed/extension.sh
 var shExtBytes []byte
 
+//go:embed embed/extension.go.tmpl
+var goExtBytes []byte
+
+//go:embed embed/extension.go.sh
+var goWrapperBytes []byte
+
 func NewCmdExtensionCreate() *cobra.Command {
 	var flags struct {
 		language string
@@ -107,12 +177,19 @@ func NewCmdExtensionCreate() *cobra.Command {
 				case ".sh":
 					cmd.Println("Detected shell extension")
 					language = "sh"
+				case ".go":
+					cmd.Println("Detected go extension")
+					language = "go"
 				default:
 					cmd.Println("No language detected, defaulting to shell")
 					language = "sh"
 				}
 			}
 
+			if language == "go" {
+				return createGoExtension(cmd, strings.TrimSuffix(args[0], ".go"))
+			}
+
 			cmd.Printf("Creating extension %s\n", args[0])
 			f, err := os.Create(args[0])
 			if err != nil {
@@ -149,12 +226,113 @@ func NewCmdExtensionCreate() *cobra.Command {
 
 	cmd.Flags().StringVarP(&flags.language, "language", "l", "", "language of extension")
 	_ = cmd.RegisterFlagCompletionFunc("language", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		return []string{"sh", "python", "deno"}, cobra.ShellCompDirectiveNoFileComp
+		return []string{"sh", "python", "deno", "go"}, cobra.ShellCompDirectiveNoFileComp
 	})
 
 	return cmd
 }
 
+// sdkVersion returns the version of the sdk required by go extensions: the one sunbeam was built from if it was released, the main branch otherwise.
+// The go command resolves the branch to a pseudo-version on the first build.
+func sdkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || !strings.HasPrefix(info.Main.Version, "v") || strings.Contains(info.Main.Version, "+") {
+		return "main"
+	}
+
+	return info.Main.Version
+}
+
+// createGoExtension creates a go module in dir, with a shell entrypoint building it once and running the binary
+func createGoExtension(cmd *cobra.Command, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+
+	cmd.Printf("Creating extension %s\n", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create extension: %w", err)
+	}
+
+	name := filepath.Base(dir)
+	files := []struct {
+		name string
+		data []byte
+		perm os.FileMode
+	}{
+		{"go.mod", []byte(fmt.Sprintf("module %s\n\ngo 1.21\n\nrequire github.com/pomdtr/sunbeam %s\n", name, sdkVersion())), 0644},
+		{"main.go", goExtBytes, 0644},
+		{".gitignore", []byte("/.build/\n"), 0644},
+		{name + ".sh", goWrapperBytes, 0755},
+	}
+
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(dir, file.name), file.data, file.perm); err != nil {
+			return fmt.Errorf("failed to write extension: %w", err)
+		}
+	}
+
+	entrypoint := filepath.Join(dir, name+".sh")
+	cmd.Printf("✅ Created go extension %s\n", dir)
+	cmd.Printf("Run `go mod tidy` in %s to fetch the sdk, then install it with `sunbeam extension install %s`\n", dir, entrypoint)
+	return nil
+}
+
+func NewCmdExtensionPack() *cobra.Command {
+	var flags struct {
+		Output     string
+		Entrypoint string
+	}
+
+	cmd := &cobra.Command{
+		Use:   "pack <dir>",
+		Short: "Pack a directory into an extension bundle",
+		Long: fmt.Sprintf(`Pack a directory into a .tar.gz or .zip extension bundle.
+
+The directory must contain a %s file pointing to the entrypoint of the extension, use --entrypoint to create it.`, extensions.BundleDescriptor),
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+
+			if flags.Entrypoint != "" {
+				bts, err := json.MarshalIndent(extensions.Bundle{Entrypoint: filepath.ToSlash(flags.Entrypoint)}, "", "  ")
+				if err != nil {
+					return err
+				}
+
+				if err := os.WriteFile(filepath.Join(dir, extensions.BundleDescriptor), append(bts, '\n'), 0644); err != nil {
+					return fmt.Errorf("failed to write bundle descriptor: %w", err)
+				}
+			}
+
+			output := flags.Output
+			if output == "" {
+				output = filepath.Base(dir) + ".tar.gz"
+			}
+
+			if err := extensions.PackBundle(dir, output); err != nil {
+				return fmt.Errorf("failed to pack extension: %w", err)
+			}
+
+			checksum, err := extensions.Sha256(output)
+			if err != nil {
+				return err
+			}
+
+			cmd.Printf("✅ Packed %s to %s (sha256 %s)\n", args[0], output, checksum)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&flags.Output, "output", "o", "", "path of the bundle, the format is inferred from its extension (default: <dir>.tar.gz)")
+	cmd.Flags().StringVar(&flags.Entrypoint, "entrypoint", "", "entrypoint of the extension, relative to the directory")
+
+	return cmd
+}
+
 func NewCmdExtensionEdit(cfg config.Config) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:       "edit <alias>",
@@ -163,10 +341,14 @@ func NewCmdExtensionEdit(cfg config.Config) *cobra.Command {
 		Args:      cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			origin := cfg.Extensions[args[0]].Origin
-			if strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://") {
+			if extensions.IsRemote(origin) {
 				return fmt.Errorf("cannot edit remote extensions")
 			}
 
+			if extensions.IsBundle(origin) {
+				return fmt.Errorf("cannot edit bundles, edit the packed directory instead")
+			}
+
 			editCmd := exec.Command("sunbeam", "edit", cfg.Resolve(origin))
 			editCmd.Stdin = os.Stdin
 			editCmd.Stdout = os.Stdout
@@ -179,215 +361,1537 @@ func NewCmdExtensionEdit(cfg config.Config) *cobra.Command {
 	return cmd
 }
 
-func NewCmdExtensionInstall(cfg config.Config) *cobra.Command {
+func NewCmdExtensionDev(cfg config.Config) *cobra.Command {
 	var flags struct {
-		Alias string
+		Watch  []string
+		Params map[string]string
 	}
 
 	cmd := &cobra.Command{
-		Use:     "install <origin>",
-		Short:   "Install sunbeam extensions",
-		Aliases: []string{"add"},
-		Args:    cobra.ExactArgs(1),
+		Use:   "dev <path> [command]",
+		Short: "Run a local extension, and reload it whenever it is modified",
+		Args:  cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			origin, err := normalizeOrigin(args[0])
+			if !isatty.IsTerminal(os.Stdout.Fd()) {
+				return fmt.Errorf("dev mode requires a terminal")
+			}
+
+			extension, err := localExtension(cfg, args[0])
 			if err != nil {
-				return fmt.Errorf("failed to normalize origin: %w", err)
+				return err
 			}
+			entrypoint, alias := extension.Entrypoint, extension.Alias
 
-			var alias string
-			if flags.Alias != "" {
-				alias = flags.Alias
+			var commandName string
+			if len(args) > 1 {
+				commandName = args[1]
 			} else {
-				a, err := extractAlias(origin)
-				if err != nil {
-					return fmt.Errorf("failed to get alias: %w", err)
+				var names []string
+				for _, command := range extension.Manifest.Commands {
+					switch command.Mode {
+					case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
+						names = append(names, command.Name)
+					}
+				}
+
+				if len(names) != 1 {
+					return fmt.Errorf("specify the command to run, available commands: %s", strings.Join(names, ", "))
 				}
-				alias = a
+				commandName = names[0]
 			}
 
-			if _, err := extensions.LoadExtension(origin); err != nil {
-				return fmt.Errorf("failed to load extension: %w", err)
+			command, ok := extension.Command(commandName)
+			if !ok {
+				return fmt.Errorf("command %s not found", commandName)
+			}
+
+			switch command.Mode {
+			case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter, sunbeam.CommandModeDetail:
+			default:
+				return fmt.Errorf("command %s cannot be run in dev mode, only search, filter and detail commands have a view", commandName)
 			}
 
-			if _, ok := cfg.Extensions[alias]; ok {
-				return fmt.Errorf("extension %s already exists", alias)
+			preferences, err := tui.ExtractPreferencesFromEnv(alias, extension)
+			if err != nil {
+				return err
 			}
 
-			cfg.Extensions[alias] = config.ExtensionConfig{
-				Origin: origin,
+			params := make(map[string]any)
+			for name, value := range flags.Params {
+				params[name] = value
 			}
 
-			if err := cfg.Save(); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			paths := []string{entrypoint}
+			// a sidecar manifest is watched along the entrypoint
+			if _, err := os.Stat(entrypoint + extensions.ManifestSuffix); err == nil {
+				paths = append(paths, entrypoint+extensions.ManifestSuffix)
 			}
+			paths = append(paths, flags.Watch...)
 
-			cmd.Printf("✅ Installed %s\n", alias)
-			return nil
+			watcher, err := tui.NewWatcher(paths...)
+			if err != nil {
+				return fmt.Errorf("failed to watch files: %w", err)
+			}
+
+			runner := tui.NewRunner(extension, sunbeam.Payload{
+				Command:     command.Name,
+				Preferences: preferences,
+				Params:      params,
+			})
+
+			return tui.DrawDev(tui.NewDevServer(extension, runner, watcher))
 		},
 	}
 
-	cmd.Flags().StringVar(&flags.Alias, "alias", "", "alias for extension")
+	cmd.Flags().StringSliceVar(&flags.Watch, "watch", nil, "additional files or directories to watch")
+	cmd.Flags().StringToStringVar(&flags.Params, "param", nil, "params passed to the command")
 
 	return cmd
+}
+
+// localExtension loads an extension which is not installed, from the path of its entrypoint
+func localExtension(cfg config.Config, path string) (extensions.Extension, error) {
+	entrypoint, err := filepath.Abs(path)
+	if err != nil {
+		return extensions.Extension{}, err
+	}
+
+	if _, err := os.Stat(entrypoint); err != nil {
+		return extensions.Extension{}, fmt.Errorf("failed to find entrypoint: %w", err)
+	}
+
+	alias, err := extractAlias(entrypoint)
+	if err != nil {
+		return extensions.Extension{}, fmt.Errorf("failed to get alias: %w", err)
+	}
+
+	extension := extensions.Extension{
+		Alias:      alias,
+		Entrypoint: entrypoint,
+		Timeouts:   extensions.NewTimeouts(cfg.ExtensionTimeouts(alias)),
+		Env:        extensions.NewEnvPolicy(config.ExtensionConfig{Origin: entrypoint}),
+	}
+
+	// the extension is not installed, so its manifest is never indexed
+	extension.Manifest, err = extension.ExtractManifest()
+	if err != nil {
+		return extensions.Extension{}, fmt.Errorf("failed to extract manifest: %w", err)
+	}
 
+	return extension, nil
 }
 
-func NewCmdExtensionRename(cfg config.Config) *cobra.Command {
-	return &cobra.Command{
-		Use:     "rename <alias> <new-alias>",
-		Short:   "Rename sunbeam extensions",
-		Aliases: []string{"mv"},
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) == 0 {
-				return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
-			}
+func NewCmdExtensionTest(cfg config.Config) *cobra.Command {
+	var flags struct {
+		File string
+		Run  string
+		JSON bool
+	}
 
-			return nil, cobra.ShellCompDirectiveNoFileComp
-		},
-		Args: cobra.ExactArgs(2),
+	cmd := &cobra.Command{
+		Use:   "test <path>",
+		Short: "Run the test cases of a local extension",
+		Long: fmt.Sprintf(`Run the test cases of a local extension.
+
+The test cases are read from the file next to the entrypoint, with the %s suffix.
+Each case runs a command, and checks the validity of its output, the count and titles of its items and the presence of actions.`, extensions.TestSuffix),
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if _, ok := cfg.Extensions[args[1]]; ok {
-				return fmt.Errorf("extension %s already exists", args[1])
+			extension, err := localExtension(cfg, args[0])
+			if err != nil {
+				return err
 			}
 
-			extension, ok := cfg.Extensions[args[0]]
-			if !ok {
-				return fmt.Errorf("extension %s not found", args[0])
+			suitePath := flags.File
+			if suitePath == "" {
+				suitePath = extension.Entrypoint + extensions.TestSuffix
 			}
 
-			delete(cfg.Extensions, args[0])
-			cfg.Extensions[args[1]] = extension
-
-			if err := cfg.Save(); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
+			suite, err := extensions.LoadTestSuite(suitePath)
+			if err != nil {
+				return err
 			}
 
-			cmd.Printf("✅ Renamed %s to %s\n", args[0], args[1])
-			return nil
-		},
-	}
-}
+			var filter *regexp.Regexp
+			if flags.Run != "" {
+				filter, err = regexp.Compile(flags.Run)
+				if err != nil {
+					return fmt.Errorf("invalid run pattern: %w", err)
+				}
+			}
 
-func NewCmdExtensionUpgrade(cfg config.Config) *cobra.Command {
-	flags := struct {
-		All bool
-	}{}
+			results := make([]extensions.TestResult, 0, len(suite.Cases))
+			var failed int
+			for _, testCase := range suite.Cases {
+				if filter != nil && !filter.MatchString(testCase.Name) {
+					continue
+				}
 
-	cmd := &cobra.Command{
-		Use:       "upgrade",
-		Short:     "Upgrade sunbeam extensions",
-		ValidArgs: cfg.Aliases(),
-		Args:      cobra.MaximumNArgs(1),
-		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) == 0 && !flags.All {
-				return fmt.Errorf("either provide an extension or use --all")
-			}
+				result := extension.RunTest(cmd.Context(), testCase)
+				if !result.Passed {
+					failed++
+				}
+				results = append(results, result)
 
-			return nil
-		},
-		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) > 0 {
-				extension, ok := cfg.Extensions[args[0]]
-				if !ok {
-					return fmt.Errorf("extension %s not found", args[0])
+				if flags.JSON {
+					continue
 				}
 
-				if err := extensions.Upgrade(extension); err != nil {
-					return fmt.Errorf("failed to upgrade extension: %w", err)
+				if result.Passed {
+					cmd.Printf("✅ %s (%s)\n", result.Name, result.Duration.Round(time.Millisecond))
+					continue
 				}
 
-				cmd.Printf("✅ Upgraded %s\n", args[0])
-				return nil
+				cmd.Printf("❌ %s (%s)\n", result.Name, result.Duration.Round(time.Millisecond))
+				for _, failure := range result.Failures {
+					for _, line := range strings.Split(failure, "\n") {
+						cmd.Printf("    %s\n", line)
+					}
+				}
 			}
 
-			cmd.Printf("Upgrading %d extensions...\n\n", len(cfg.Extensions))
-			for alias, extension := range cfg.Extensions {
-				if err := extensions.Upgrade(extension); err != nil {
-					return fmt.Errorf("failed to upgrade extension %s: %w", alias, err)
+			if flags.JSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(results); err != nil {
+					return err
 				}
+			} else {
+				cmd.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+			}
 
-				cmd.Printf("✅ Upgraded %s\n", alias)
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d test cases failed", failed)
 			}
 
-			cmd.Printf("\n✅ Upgraded all extensions\n")
 			return nil
 		},
 	}
 
-	cmd.Flags().BoolVar(&flags.All, "all", false, "upgrade all extensions")
+	cmd.Flags().StringVarP(&flags.File, "file", "f", "", fmt.Sprintf("path of the test cases (default: <entrypoint>%s)", extensions.TestSuffix))
+	cmd.Flags().StringVar(&flags.Run, "run", "", "only run the test cases whose name matches the regular expression")
+	cmd.Flags().BoolVar(&flags.JSON, "json", false, "output the results as json")
+
 	return cmd
 }
 
-func NewCmdExtensionList(cfg config.Config) *cobra.Command {
+func NewCmdExtensionInstall(cfg config.Config) *cobra.Command {
+	var flags struct {
+		Alias  string
+		Frozen bool
+		Yes    bool
+	}
+
 	cmd := &cobra.Command{
-		Use:     "list",
-		Short:   "List sunbeam extensions",
-		Aliases: []string{"ls"},
-		Args:    cobra.NoArgs,
+		Use:     "install <origin>",
+		Short:   "Install sunbeam extensions",
+		Aliases: []string{"add"},
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var t tableprinter.TablePrinter
-			if isatty.IsTerminal(os.Stdout.Fd()) {
-				w, _, err := term.GetSize(int(os.Stdout.Fd()))
-				if err != nil {
-					return err
-				}
-				t = tableprinter.New(os.Stdout, true, w)
-			} else {
-				t = tableprinter.New(os.Stdout, false, 0)
+			if flags.Frozen {
+				extensions.Frozen = true
 			}
 
-			for alias, extension := range cfg.Extensions {
-				t.AddField(alias)
-				t.AddField(extension.Origin)
-				t.EndRow()
+			origin, err := normalizeOrigin(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to normalize origin: %w", err)
 			}
 
-			return t.Render()
+			return installExtension(cmd, cfg, origin, flags.Alias, flags.Yes)
 		},
 	}
 
+	cmd.Flags().StringVar(&flags.Alias, "alias", "", "alias for extension")
+	cmd.Flags().BoolVar(&flags.Frozen, "frozen", false, "refuse to install extensions that are not pinned in the lockfile")
+	cmd.Flags().BoolVarP(&flags.Yes, "yes", "y", false, "install remote extensions without confirmation")
+
 	return cmd
+
 }
 
-func NewCmdExtensionRemove(cfg config.Config) *cobra.Command {
-	return &cobra.Command{
-		Use:     "remove <alias>",
-		Short:   "Remove sunbeam extensions",
-		Aliases: []string{"rm", "uninstall"},
-		Args:    cobra.MinimumNArgs(1),
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			completions := cfg.Aliases()
+// installExtension shows the manifest of the extension, and asks for confirmation before installing remote extensions
+func installExtension(cmd *cobra.Command, cfg config.Config, origin string, alias string, yes bool) error {
+	if alias == "" {
+		a, err := extractAlias(origin)
+		if err != nil {
+			return fmt.Errorf("failed to get alias: %w", err)
+		}
+		alias = a
+	}
 
-			for _, arg := range args {
-				for i, completion := range completions {
-					if completion == arg {
-						completions = append(completions[:i], completions[i+1:]...)
-						break
-					}
-				}
-			}
+	if err := validateAlias(cmd, alias); err != nil {
+		return err
+	}
 
-			return completions, cobra.ShellCompDirectiveNoFileComp
-		},
-		RunE: func(cmd *cobra.Command, args []string) error {
-			for _, arg := range args {
-				delete(cfg.Extensions, arg)
-			}
+	if _, ok := cfg.Extensions[alias]; ok {
+		return fmt.Errorf("extension %s already exists", alias)
+	}
 
-			if err := cfg.Save(); err != nil {
-				return fmt.Errorf("failed to save config: %w", err)
-			}
+	cfg.Extensions[alias] = config.ExtensionConfig{
+		Origin: origin,
+	}
 
-			if len(args) == 1 {
-				cmd.Printf("✅ Removed %s\n", args[0])
-				return nil
-			}
+	// show what is being installed before running anything
+	entrypoint, manifest, ok, err := extensions.FetchExtension(cfg, alias)
+	if err != nil {
+		return fmt.Errorf("failed to fetch extension: %w", err)
+	}
 
-			cmd.Printf("✅ Removed %d extensions\n", len(args))
+	if ok {
+		printManifest(cmd, manifest)
+		if err := checkRequirements(cmd, alias, manifest); err != nil {
+			return err
+		}
+	} else {
+		cmd.Printf("⚠️ %s has no static manifest, %s will be run to extract it\n\n", alias, entrypoint)
+	}
+
+	if extensions.IsRemote(origin) && !yes && isatty.IsTerminal(os.Stdin.Fd()) {
+		ok, err := confirm(cmd, fmt.Sprintf("Install %s?", alias))
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			cmd.Printf("Skipped %s\n", alias)
 			return nil
-		},
+		}
 	}
+
+	extension, err := extensions.LoadExtension(cfg, alias)
+	if err != nil {
+		return fmt.Errorf("failed to load extension: %w", err)
+	}
+
+	if !ok {
+		if err := checkRequirements(cmd, alias, extension.Manifest); err != nil {
+			return err
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	cmd.Printf("✅ Installed %s\n", alias)
+	return nil
+}
+
+// catalogLocation returns the catalog index set in the config, there is no default one
+func catalogLocation(cfg config.Config) (string, error) {
+	if cfg.Catalog == "" {
+		return "", fmt.Errorf("no catalog configured, set catalog to the path or url of a catalog index in %s", cfg.Path())
+	}
+
+	if strings.HasPrefix(cfg.Catalog, "http://") || strings.HasPrefix(cfg.Catalog, "https://") {
+		return cfg.Catalog, nil
+	}
+
+	return cfg.Resolve(cfg.Catalog), nil
+}
+
+// installedOrigins returns the origins of the installed extensions, to flag the catalog entries already installed.
+// Local origins are resolved from the config directory, as the ones of the catalog are resolved from its location.
+func installedOrigins(cfg config.Config) map[string]bool {
+	origins := make(map[string]bool)
+	for _, extensionConfig := range cfg.Extensions {
+		origins[resolveConfigOrigin(cfg, extensionConfig.Origin)] = true
+	}
+
+	return origins
+}
+
+func NewCmdExtensionSearch(cfg config.Config) *cobra.Command {
+	var flags struct {
+		JSON    bool
+		Install bool
+		Yes     bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "Search extensions in the catalog",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var query string
+			if len(args) > 0 {
+				query = args[0]
+			}
+
+			location, err := catalogLocation(cfg)
+			if err != nil {
+				return err
+			}
+
+			catalog, err := extensions.LoadCatalog(location)
+			if err != nil {
+				return err
+			}
+
+			entries := catalog.Search(query)
+			if flags.Install {
+				if len(entries) == 0 {
+					return fmt.Errorf("no extension matches %s", query)
+				}
+
+				// the best match goes through the same checks and confirmation as extension install
+				if err := validateAlias(cmd, entries[0].Name); err != nil {
+					return err
+				}
+
+				origin, err := normalizeOrigin(entries[0].Origin)
+				if err != nil {
+					return fmt.Errorf("failed to normalize origin: %w", err)
+				}
+
+				return installExtension(cmd, cfg, origin, entries[0].Name, flags.Yes)
+			}
+
+			if flags.JSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				encoder.SetEscapeHTML(false)
+				return encoder.Encode(entries)
+			}
+
+			if len(entries) == 0 {
+				cmd.PrintErrf("No extension matches %s\n", query)
+				return nil
+			}
+
+			var t tableprinter.TablePrinter
+			if isatty.IsTerminal(os.Stdout.Fd()) {
+				w, _, err := term.GetSize(int(os.Stdout.Fd()))
+				if err != nil {
+					return err
+				}
+				t = tableprinter.New(os.Stdout, true, w)
+			} else {
+				t = tableprinter.New(os.Stdout, false, 0)
+			}
+
+			installed := installedOrigins(cfg)
+			faint := lipgloss.NewStyle().Faint(true)
+			for _, entry := range entries {
+				commands := make([]string, 0, len(entry.Manifest.Commands))
+				for _, command := range entry.Manifest.Commands {
+					commands = append(commands, command.Name)
+				}
+
+				t.AddField(entry.Name)
+				t.AddField(entry.Manifest.Title)
+				t.AddField(entry.Manifest.Description)
+				t.AddField(strings.Join(commands, ", "), tableprinter.WithColor(func(s string) string {
+					return faint.Render(s)
+				}))
+				if installed[entry.Origin] {
+					t.AddField("installed")
+				} else {
+					t.AddField("")
+				}
+				t.EndRow()
+			}
+
+			return t.Render()
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.JSON, "json", false, "output the matching extensions as json")
+	cmd.Flags().BoolVar(&flags.Install, "install", false, "install the best match")
+	cmd.Flags().BoolVarP(&flags.Yes, "yes", "y", false, "install without confirmation")
+
+	return cmd
+}
+
+func NewCmdExtensionBrowse(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "browse",
+		Short: "Browse the extension catalog",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !isInteractive() {
+				return fmt.Errorf("browse requires a terminal, use search instead")
+			}
+
+			history, err := history.Load(filepath.Join(utils.CacheDir(), "catalog-history.json"))
+			if err != nil {
+				return err
+			}
+
+			page, err := newCatalogPage(cfg, history)
+			if err != nil {
+				return err
+			}
+
+			return tui.Draw(page)
+		},
+	}
+}
+
+// newCatalogPage lists the entries of the catalog, with an action to install the ones which are not installed yet
+func newCatalogPage(cfg config.Config, history history.History) (*tui.RootList, error) {
+	location, err := catalogLocation(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	page := tui.NewRootList("Extension Catalog", history, func() (config.Config, []sunbeam.ListItem, error) {
+		// reload the config, so that extensions installed from the page are flagged
+		cfg, err := config.Load(cfg.Path())
+		if err != nil {
+			return config.Config{}, nil, err
+		}
+
+		catalog, err := extensions.LoadCatalog(location)
+		if err != nil {
+			return config.Config{}, nil, err
+		}
+
+		installed := installedOrigins(cfg)
+		items := make([]sunbeam.ListItem, 0, len(catalog.Extensions))
+		for _, entry := range catalog.Search("") {
+			items = append(items, catalogListItem(entry, installed[entry.Origin]))
+		}
+
+		return cfg, items, nil
+	})
+	page.SetShowDetail(true)
+
+	return page, nil
+}
+
+func catalogListItem(entry extensions.CatalogEntry, installed bool) sunbeam.ListItem {
+	var markdown strings.Builder
+	markdown.WriteString(fmt.Sprintf("# %s\n\n", entry.Manifest.Title))
+	if entry.Manifest.Description != "" {
+		markdown.WriteString(fmt.Sprintf("%s\n\n", entry.Manifest.Description))
+	}
+
+	if len(entry.Manifest.Commands) > 0 {
+		markdown.WriteString("## Commands\n\n")
+		for _, command := range entry.Manifest.Commands {
+			markdown.WriteString(fmt.Sprintf("- `%s`: %s\n", command.Name, command.Title))
+		}
+		markdown.WriteString("\n")
+	}
+
+	if len(entry.Manifest.Preferences) > 0 {
+		markdown.WriteString("## Preferences\n\n")
+		for _, preference := range entry.Manifest.Preferences {
+			markdown.WriteString(fmt.Sprintf("- `%s`: %s\n", preference.Name, preference.Title))
+		}
+		markdown.WriteString("\n")
+	}
+
+	markdown.WriteString(fmt.Sprintf("## Origin\n\n`%s`\n", entry.Origin))
+
+	item := sunbeam.ListItem{
+		Id:       fmt.Sprintf("catalog - %s", entry.Name),
+		Title:    entry.Manifest.Title,
+		Subtitle: entry.Manifest.Description,
+		Detail:   sunbeam.ListItemDetail{Markdown: markdown.String()},
+	}
+
+	if item.Title == "" {
+		item.Title = entry.Name
+	}
+
+	if installed {
+		item.Accessories = []string{"Installed"}
+	} else {
+		// run the install command in the terminal, so that the manifest is shown and confirmed as with extension install
+		item.Actions = append(item.Actions, sunbeam.Action{
+			Title: "Install",
+			Type:  sunbeam.ActionTypeExec,
+			Exec: &sunbeam.ExecAction{
+				Command:     fmt.Sprintf("sunbeam extension install --alias %s %s", shellQuote(entry.Name), shellQuote(entry.Origin)),
+				Interactive: true,
+			},
+		})
+	}
+
+	item.Actions = append(item.Actions, sunbeam.Action{
+		Title: "Copy Origin",
+		Key:   "c",
+		Type:  sunbeam.ActionTypeCopy,
+		Copy:  &sunbeam.CopyAction{Text: entry.Origin, Exit: true},
+	})
+
+	return item
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func NewCmdExtensionRename(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rename <alias> <new-alias>",
+		Short:   "Rename sunbeam extensions",
+		Aliases: []string{"mv"},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateAlias(cmd, args[1]); err != nil {
+				return err
+			}
+
+			if _, ok := cfg.Extensions[args[1]]; ok {
+				return fmt.Errorf("extension %s already exists", args[1])
+			}
+
+			extension, ok := cfg.Extensions[args[0]]
+			if !ok {
+				return fmt.Errorf("extension %s not found", args[0])
+			}
+
+			delete(cfg.Extensions, args[0])
+			cfg.Extensions[args[1]] = extension
+
+			if err := cfg.Save(); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+
+			cmd.Printf("✅ Renamed %s to %s\n", args[0], args[1])
+			return nil
+		},
+	}
+}
+
+func NewCmdExtensionUpgrade(cfg config.Config) *cobra.Command {
+	flags := struct {
+		All    bool
+		Frozen bool
+		Yes    bool
+	}{}
+
+	cmd := &cobra.Command{
+		Use:       "upgrade",
+		Short:     "Upgrade sunbeam extensions",
+		ValidArgs: cfg.Aliases(),
+		Args:      cobra.MaximumNArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !flags.All {
+				return fmt.Errorf("either provide an extension or use --all")
+			}
+
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if flags.Frozen {
+				extensions.Frozen = true
+			}
+
+			if len(args) > 0 {
+				if _, ok := cfg.Extensions[args[0]]; !ok {
+					return fmt.Errorf("extension %s not found", args[0])
+				}
+
+				if _, err := upgradeExtension(cmd, cfg, args[0], flags.Yes); err != nil {
+					return fmt.Errorf("failed to upgrade extension: %w", err)
+				}
+
+				return nil
+			}
+
+			return upgradeAll(cmd, cfg, flags.Yes)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.All, "all", false, "upgrade all extensions")
+	cmd.Flags().BoolVar(&flags.Frozen, "frozen", false, "refuse to upgrade extensions whose checksum changed")
+	cmd.Flags().BoolVarP(&flags.Yes, "yes", "y", false, "upgrade without showing the changes and asking for confirmation")
+	return cmd
+}
+
+// OutdatedReport is the result of checking a remote extension, the error is set if the check failed
+type OutdatedReport struct {
+	extensions.OutdatedResult
+	Error string `json:"error,omitempty"`
+}
+
+func NewCmdExtensionOutdated(cfg config.Config) *cobra.Command {
+	var flags struct {
+		JSON bool
+	}
+
+	cmd := &cobra.Command{
+		Use:       "outdated [alias...]",
+		Short:     "Check which remote extensions changed upstream, without upgrading them",
+		ValidArgs: cfg.Aliases(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases := args
+			if len(aliases) == 0 {
+				for _, alias := range sortedAliases(cfg) {
+					if extensions.IsRemote(cfg.Extensions[alias].Origin) {
+						aliases = append(aliases, alias)
+					}
+				}
+			}
+
+			for _, alias := range aliases {
+				extensionConfig, ok := cfg.Extensions[alias]
+				if !ok {
+					return fmt.Errorf("extension %s not found", alias)
+				}
+
+				if !extensions.IsRemote(extensionConfig.Origin) {
+					return fmt.Errorf("extension %s is not a remote extension", alias)
+				}
+			}
+
+			index, err := extensions.LoadIndex(extensions.IndexPath)
+			if err != nil {
+				return err
+			}
+
+			// the lockfile is never saved, checking must not pin anything
+			lockfile, err := extensions.LoadLockfile(extensions.LockPath(cfg))
+			if err != nil {
+				return err
+			}
+
+			reports := make([]OutdatedReport, len(aliases))
+			var wg sync.WaitGroup
+			for i, alias := range aliases {
+				wg.Add(1)
+				go func(i int, alias string) {
+					defer wg.Done()
+
+					res, err := extensions.CheckOutdated(cfg, alias, index, lockfile)
+					if err != nil {
+						reports[i] = OutdatedReport{
+							OutdatedResult: extensions.OutdatedResult{Alias: alias, Origin: cfg.Extensions[alias].Origin},
+							Error:          err.Error(),
+						}
+						return
+					}
+
+					reports[i] = OutdatedReport{OutdatedResult: res}
+				}(i, alias)
+			}
+			wg.Wait()
+
+			// keep the validators refreshed by unconditional requests
+			if err := index.Save(); err != nil {
+				return err
+			}
+
+			if flags.JSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				encoder.SetEscapeHTML(false)
+				if err := encoder.Encode(reports); err != nil {
+					return err
+				}
+			} else if err := printOutdatedReports(reports); err != nil {
+				return err
+			}
+
+			var failed int
+			for _, report := range reports {
+				if report.Error != "" {
+					failed++
+				}
+			}
+
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("failed to check %d of %d extensions", failed, len(reports))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.JSON, "json", false, "output the results as json")
+
+	return cmd
+}
+
+func printOutdatedReports(reports []OutdatedReport) error {
+	var t tableprinter.TablePrinter
+	if isatty.IsTerminal(os.Stdout.Fd()) {
+		w, _, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return err
+		}
+		t = tableprinter.New(os.Stdout, true, w)
+	} else {
+		t = tableprinter.New(os.Stdout, false, 0)
+	}
+
+	statusStyles := map[string]lipgloss.Style{
+		"up to date": lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		"outdated":   lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+		"failed":     lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+	}
+
+	for _, report := range reports {
+		status := "up to date"
+		if report.Error != "" {
+			status = "failed"
+		} else if report.Outdated {
+			status = "outdated"
+		}
+
+		t.AddField(report.Alias)
+		style := statusStyles[status]
+		t.AddField(status, tableprinter.WithColor(func(s string) string {
+			return style.Render(s)
+		}))
+
+		switch {
+		case report.Error != "":
+			t.AddField(strings.Join(strings.Fields(report.Error), " "))
+		case report.Outdated:
+			t.AddField(fmt.Sprintf("%s → %s", shortVersion(report.Current), shortVersion(report.Latest)))
+		default:
+			t.AddField(shortVersion(report.Current))
+		}
+		t.EndRow()
+	}
+
+	return t.Render()
+}
+
+// shortVersion abbreviates checksums and commits, as git does
+func shortVersion(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+
+	if len(version) > 12 {
+		return version[:12]
+	}
+
+	return version
+}
+
+type upgradeStatus int
+
+const (
+	upgradeStatusUpgraded upgradeStatus = iota
+	upgradeStatusUpToDate
+	upgradeStatusSkipped
+)
+
+// upgradeAll checks and downloads the new versions of all extensions concurrently, then applies them one at a time so that each change can be reviewed.
+// Failures are reported in the summary instead of aborting the other upgrades.
+func upgradeAll(cmd *cobra.Command, cfg config.Config, yes bool) error {
+	aliases := sortedAliases(cfg)
+	cmd.Printf("Upgrading %d extensions...\n\n", len(aliases))
+
+	index, err := extensions.LoadIndex(extensions.IndexPath)
+	if err != nil {
+		return err
+	}
+
+	lockfile, err := extensions.LoadLockfile(extensions.LockPath(cfg))
+	if err != nil {
+		return err
+	}
+
+	upgrades := make([]*extensions.StagedUpgrade, len(aliases))
+	upToDate := make([]bool, len(aliases))
+	errs := make([]error, len(aliases))
+
+	var wg sync.WaitGroup
+	for i, alias := range aliases {
+		if !extensions.IsRemote(cfg.Extensions[alias].Origin) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, alias string) {
+			defer wg.Done()
+
+			// unchanged extensions are not downloaded again, a failed check falls back to a full download
+			if res, err := extensions.CheckOutdated(cfg, alias, index, lockfile); err == nil && !res.Outdated {
+				upToDate[i] = true
+				return
+			}
+
+			upgrades[i], errs[i] = extensions.StageUpgrade(cfg, alias)
+		}(i, alias)
+	}
+	wg.Wait()
+
+	// the validators refreshed by the checks must be saved before the upgrades are applied
+	if err := index.Save(); err != nil {
+		return err
+	}
+
+	counts := make(map[upgradeStatus]int)
+	var failed int
+	for i, alias := range aliases {
+		var status upgradeStatus
+		var err error
+		switch {
+		case upToDate[i]:
+			cmd.Printf("✅ %s is already up to date\n", alias)
+			status = upgradeStatusUpToDate
+		case errs[i] != nil:
+			err = errs[i]
+		case upgrades[i] != nil:
+			status, err = reviewUpgrade(cmd, upgrades[i], yes)
+		default:
+			status, err = upgradeExtension(cmd, cfg, alias, yes)
+		}
+
+		if err != nil {
+			cmd.Printf("❌ Failed to upgrade %s: %s\n", alias, err)
+			failed++
+			continue
+		}
+
+		counts[status]++
+	}
+
+	cmd.Printf("\n%d upgraded, %d up to date, %d skipped, %d failed\n", counts[upgradeStatusUpgraded], counts[upgradeStatusUpToDate], counts[upgradeStatusSkipped], failed)
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("failed to upgrade %d of %d extensions", failed, len(aliases))
+	}
+
+	return nil
+}
+
+// upgradeExtension stages the new version of remote extensions, and shows the changes before applying them
+func upgradeExtension(cmd *cobra.Command, cfg config.Config, alias string, yes bool) (upgradeStatus, error) {
+	if !extensions.IsRemote(cfg.Extensions[alias].Origin) {
+		res, err := extensions.Upgrade(cfg, alias)
+		if err != nil {
+			return upgradeStatusUpgraded, err
+		}
+
+		printUpgradeResult(cmd, alias, res)
+		return upgradeStatusUpgraded, nil
+	}
+
+	upgrade, err := extensions.StageUpgrade(cfg, alias)
+	if err != nil {
+		return upgradeStatusUpgraded, err
+	}
+
+	return reviewUpgrade(cmd, upgrade, yes)
+}
+
+// reviewUpgrade shows the changes of a staged upgrade, and applies it once confirmed
+func reviewUpgrade(cmd *cobra.Command, upgrade *extensions.StagedUpgrade, yes bool) (upgradeStatus, error) {
+	defer upgrade.Discard()
+
+	if upgrade.IsUpToDate() {
+		printUpgradeResult(cmd, upgrade.Alias, extensions.UpgradeResult{
+			OldSha256: upgrade.OldSha256,
+			NewSha256: upgrade.NewSha256,
+			OldCommit: upgrade.OldCommit,
+			NewCommit: upgrade.NewCommit,
+		})
+		return upgradeStatusUpToDate, nil
+	}
+
+	if !yes {
+		if !isatty.IsTerminal(os.Stdin.Fd()) {
+			return upgradeStatusUpgraded, fmt.Errorf("cannot ask for confirmation, use --yes to upgrade non-interactively")
+		}
+
+		diff, err := upgrade.Diff()
+		if err != nil {
+			return upgradeStatusUpgraded, err
+		}
+
+		printDiff(cmd, diff)
+		if changes, ok := upgrade.ManifestChanges(); ok {
+			printManifestChanges(cmd, changes)
+		} else {
+			cmd.Println("The manifest could not be compared, the new version does not declare a static manifest")
+			cmd.Println("It will be extracted and compared once the upgrade is applied")
+			cmd.Println()
+		}
+
+		ok, err := confirm(cmd, fmt.Sprintf("Upgrade %s?", upgrade.Alias))
+		if err != nil {
+			return upgradeStatusUpgraded, err
+		}
+
+		if !ok {
+			cmd.Printf("Skipped %s\n", upgrade.Alias)
+			return upgradeStatusSkipped, nil
+		}
+	}
+
+	// the manifest of entrypoints without a static one is only extracted once they are applied
+	compared := upgrade.ManifestAvailable
+	res, err := upgrade.Apply()
+	if err != nil {
+		return upgradeStatusUpgraded, err
+	}
+
+	if !compared || yes {
+		changes, _ := upgrade.ManifestChanges()
+		printManifestChanges(cmd, changes)
+	}
+
+	printUpgradeResult(cmd, upgrade.Alias, res)
+	return upgradeStatusUpgraded, nil
+}
+
+func printDiff(cmd *cobra.Command, diff string) {
+	added := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	removed := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	header := lipgloss.NewStyle().Bold(true)
+
+	for _, line := range strings.Split(strings.TrimRight(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			cmd.Println(header.Render(line))
+		case strings.HasPrefix(line, "+"):
+			cmd.Println(added.Render(line))
+		case strings.HasPrefix(line, "-"):
+			cmd.Println(removed.Render(line))
+		default:
+			cmd.Println(line)
+		}
+	}
+	cmd.Println()
+}
+
+// checkRequirements refuses extensions that do not support the current platform.
+// Missing requirements are only reported, since they can be installed afterwards.
+func checkRequirements(cmd *cobra.Command, alias string, manifest sunbeam.Manifest) error {
+	err := extensions.CheckRequirements(alias, manifest)
+	if err == nil {
+		return nil
+	}
+
+	var requirementsErr extensions.RequirementsError
+	if errors.As(err, &requirementsErr) && !requirementsErr.UnsupportedPlatform() {
+		cmd.Printf("⚠️ %s\n\n", requirementsErr.Error())
+		return nil
+	}
+
+	return err
+}
+
+func printManifest(cmd *cobra.Command, manifest sunbeam.Manifest) {
+	cmd.Println(lipgloss.NewStyle().Bold(true).Render(manifest.Title))
+	if manifest.Description != "" {
+		cmd.Println(manifest.Description)
+	}
+	cmd.Println()
+
+	if len(manifest.Platforms) > 0 {
+		platforms := make([]string, 0, len(manifest.Platforms))
+		for _, platform := range manifest.Platforms {
+			platforms = append(platforms, string(platform))
+		}
+		cmd.Printf("Platforms: %s\n", strings.Join(platforms, ", "))
+	}
+
+	if len(manifest.Requirements) > 0 {
+		cmd.Println("Requirements:")
+		for _, requirement := range manifest.Requirements {
+			if requirement.Link != "" {
+				cmd.Printf("  %s (%s)\n", requirement.Name, requirement.Link)
+				continue
+			}
+			cmd.Printf("  %s\n", requirement.Name)
+		}
+	}
+
+	if len(manifest.Commands) > 0 {
+		cmd.Println("Commands:")
+		for _, command := range manifest.Commands {
+			cmd.Printf("  %s (%s, %s)\n", command.Name, command.Title, command.Mode)
+		}
+	}
+
+	if len(manifest.Preferences) > 0 {
+		cmd.Println("Preferences:")
+		for _, preference := range manifest.Preferences {
+			if preference.Optional {
+				cmd.Printf("  %s (%s)\n", preference.Name, preference.Title)
+				continue
+			}
+			cmd.Printf("  %s (%s, required)\n", preference.Name, preference.Title)
+		}
+	}
+	cmd.Println()
+}
+
+func printManifestChanges(cmd *cobra.Command, changes extensions.ManifestChanges) {
+	if changes.IsEmpty() {
+		cmd.Println("No changes to the manifest")
+		cmd.Println()
+		return
+	}
+
+	cmd.Println("Manifest changes:")
+	for _, command := range changes.AddedCommands {
+		cmd.Printf("  + command %s (%s)\n", command.Name, command.Title)
+	}
+	for _, command := range changes.RemovedCommands {
+		cmd.Printf("  - command %s (%s)\n", command.Name, command.Title)
+	}
+	for _, preference := range changes.AddedPreferences {
+		if preference.Optional {
+			cmd.Printf("  + preference %s (%s)\n", preference.Name, preference.Title)
+			continue
+		}
+		cmd.Printf("  + preference %s (%s, required)\n", preference.Name, preference.Title)
+	}
+	for _, preference := range changes.RemovedPreferences {
+		cmd.Printf("  - preference %s (%s)\n", preference.Name, preference.Title)
+	}
+	cmd.Println()
+}
+
+func confirm(cmd *cobra.Command, message string) (bool, error) {
+	cmd.Printf("%s [y/N] ", message)
+
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func printUpgradeResult(cmd *cobra.Command, alias string, res extensions.UpgradeResult) {
+	if res.NewSha256 == "" {
+		cmd.Printf("✅ Upgraded %s\n", alias)
+		return
+	}
+
+	if res.OldSha256 == res.NewSha256 && res.OldCommit == res.NewCommit {
+		if res.NewCommit != "" {
+			cmd.Printf("✅ %s is already up to date (commit %s)\n", alias, res.NewCommit)
+			return
+		}
+
+		cmd.Printf("✅ %s is already up to date (sha256 %s)\n", alias, res.NewSha256)
+		return
+	}
+
+	cmd.Printf("✅ Upgraded %s\n", alias)
+	if res.NewCommit != "" {
+		if res.OldCommit != "" {
+			cmd.Printf("   old commit: %s\n", res.OldCommit)
+		}
+		cmd.Printf("   new commit: %s\n", res.NewCommit)
+	}
+	if res.OldSha256 != "" {
+		cmd.Printf("   old sha256: %s\n", res.OldSha256)
+	}
+	cmd.Printf("   new sha256: %s\n", res.NewSha256)
+}
+
+func NewCmdExtensionRollback(cfg config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback <alias> [version]",
+		Short: "Rollback a remote extension to a previous version",
+		Args:  cobra.RangeArgs(1, 2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return cfg.Aliases(), cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var id int
+			if len(args) > 1 {
+				v, err := strconv.Atoi(args[1])
+				if err != nil {
+					return fmt.Errorf("invalid version: %s", args[1])
+				}
+				id = v
+			}
+
+			version, err := extensions.Rollback(cfg, args[0], id)
+			if err != nil {
+				return fmt.Errorf("failed to rollback extension: %w", err)
+			}
+
+			if version.Commit != "" {
+				cmd.Printf("✅ Rolled back %s to version %d (commit %s, downloaded %s)\n", args[0], version.ID, version.Commit, version.DownloadedAt.Format(time.DateTime))
+				return nil
+			}
+
+			cmd.Printf("✅ Rolled back %s to version %d (sha256 %s, downloaded %s)\n", args[0], version.ID, version.Sha256, version.DownloadedAt.Format(time.DateTime))
+			return nil
+		},
+	}
+
+	cmd.AddCommand(NewCmdExtensionRollbackList(cfg))
+	return cmd
+}
+
+func NewCmdExtensionRollbackList(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:       "list <alias>",
+		Short:     "List the available versions of a remote extension",
+		Aliases:   []string{"ls"},
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: cfg.Aliases(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			extensionConfig, ok := cfg.Extensions[args[0]]
+			if !ok {
+				return fmt.Errorf("extension %s not found", args[0])
+			}
+
+			if !extensions.IsRemote(extensionConfig.Origin) {
+				return fmt.Errorf("extension %s is not a remote extension", args[0])
+			}
+
+			versions, err := extensions.ListVersions(extensionConfig.Origin)
+			if err != nil {
+				return err
+			}
+
+			lockfile, err := extensions.LoadLockfile(extensions.LockPath(cfg))
+			if err != nil {
+				return err
+			}
+			current, _ := lockfile.Get(extensionConfig.Origin)
+
+			var t tableprinter.TablePrinter
+			if isatty.IsTerminal(os.Stdout.Fd()) {
+				w, _, err := term.GetSize(int(os.Stdout.Fd()))
+				if err != nil {
+					return err
+				}
+				t = tableprinter.New(os.Stdout, true, w)
+			} else {
+				t = tableprinter.New(os.Stdout, false, 0)
+			}
+
+			for _, version := range versions {
+				t.AddField(strconv.Itoa(version.ID))
+				if version.Commit != "" {
+					t.AddField(version.Commit)
+				} else {
+					t.AddField(version.Sha256)
+				}
+				t.AddField(version.DownloadedAt.Format(time.DateTime))
+				t.AddField(fmt.Sprintf("%d commands", len(version.Manifest.Commands)))
+				if version.Sha256 == current.Sha256 && version.Commit == current.Commit {
+					t.AddField("current")
+				} else {
+					t.AddField("")
+				}
+				t.EndRow()
+			}
+
+			return t.Render()
+		},
+	}
+}
+
+func NewCmdExtensionList(cfg config.Config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List sunbeam extensions",
+		Aliases: []string{"ls"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var t tableprinter.TablePrinter
+			if isatty.IsTerminal(os.Stdout.Fd()) {
+				w, _, err := term.GetSize(int(os.Stdout.Fd()))
+				if err != nil {
+					return err
+				}
+				t = tableprinter.New(os.Stdout, true, w)
+			} else {
+				t = tableprinter.New(os.Stdout, false, 0)
+			}
+
+			for alias, extension := range cfg.Extensions {
+				t.AddField(alias)
+				t.AddField(extension.Origin)
+				t.EndRow()
+			}
+
+			return t.Render()
+		},
+	}
+
+	return cmd
+}
+
+func NewCmdExtensionDoctor(cfg config.Config) *cobra.Command {
+	var flags struct {
+		JSON bool
+	}
+
+	cmd := &cobra.Command{
+		Use:       "doctor [alias...]",
+		Short:     "Check the health of installed extensions",
+		ValidArgs: cfg.Aliases(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases := args
+			if len(aliases) == 0 {
+				aliases = sortedAliases(cfg)
+			}
+
+			for _, alias := range aliases {
+				if _, ok := cfg.Extensions[alias]; !ok {
+					return fmt.Errorf("extension %s not found", alias)
+				}
+			}
+
+			// the lockfile is never saved, the doctor must not pin anything
+			lockfile, err := extensions.LoadLockfile(extensions.LockPath(cfg))
+			if err != nil {
+				return err
+			}
+
+			reports := make([]extensions.DoctorReport, len(aliases))
+			errs := make([]error, len(aliases))
+			var wg sync.WaitGroup
+			for i, alias := range aliases {
+				wg.Add(1)
+				go func(i int, alias string) {
+					defer wg.Done()
+					reports[i], errs[i] = extensions.Doctor(cfg, alias, lockfile)
+				}(i, alias)
+			}
+			wg.Wait()
+
+			if err := errors.Join(errs...); err != nil {
+				return err
+			}
+
+			if flags.JSON {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				encoder.SetEscapeHTML(false)
+				if err := encoder.Encode(reports); err != nil {
+					return err
+				}
+			} else if err := printDoctorReports(reports); err != nil {
+				return err
+			}
+
+			var unhealthy int
+			for _, report := range reports {
+				if !report.Healthy {
+					unhealthy++
+				}
+			}
+
+			if unhealthy > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d extensions have problems", unhealthy, len(reports))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.JSON, "json", false, "output the results as json")
+
+	return cmd
+}
+
+func printDoctorReports(reports []extensions.DoctorReport) error {
+	var t tableprinter.TablePrinter
+	if isatty.IsTerminal(os.Stdout.Fd()) {
+		w, _, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			return err
+		}
+		t = tableprinter.New(os.Stdout, true, w)
+	} else {
+		t = tableprinter.New(os.Stdout, false, 0)
+	}
+
+	statusStyles := map[extensions.DoctorStatus]lipgloss.Style{
+		extensions.DoctorStatusOK:        lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
+		extensions.DoctorStatusFailed:    lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+		extensions.DoctorStatusSkipped:   lipgloss.NewStyle().Faint(true),
+		extensions.DoctorStatusNotCached: lipgloss.NewStyle().Foreground(lipgloss.Color("3")),
+	}
+
+	for _, report := range reports {
+		for _, check := range report.Checks {
+			t.AddField(report.Alias)
+			t.AddField(check.Name)
+			style := statusStyles[check.Status]
+			t.AddField(string(check.Status), tableprinter.WithColor(func(s string) string {
+				return style.Render(s)
+			}))
+			// the table is line based, keep multiline errors on a single row
+			t.AddField(strings.Join(strings.Fields(check.Message), " "))
+			t.EndRow()
+		}
+	}
+
+	return t.Render()
+}
+
+func NewCmdExtensionRemove(cfg config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <alias>",
+		Short:   "Remove sunbeam extensions",
+		Aliases: []string{"rm", "uninstall"},
+		Args:    cobra.MinimumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			completions := cfg.Aliases()
+
+			for _, arg := range args {
+				for i, completion := range completions {
+					if completion == arg {
+						completions = append(completions[:i], completions[i+1:]...)
+						break
+					}
+				}
+			}
+
+			return completions, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, arg := range args {
+				delete(cfg.Extensions, arg)
+			}
+
+			if err := cfg.Save(); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+
+			if len(args) == 1 {
+				cmd.Printf("✅ Removed %s\n", args[0])
+			} else {
+				cmd.Printf("✅ Removed %d extensions\n", len(args))
+			}
+
+			// the config is saved already, a failure to clean the cache is not fatal
+			if err := pruneCache(cmd, cfg, false); err != nil {
+				cmd.PrintErrf("⚠️ Failed to prune the cache: %s\n", err)
+			}
+
+			return nil
+		},
+	}
+}
+
+func NewCmdExtensionPrune(cfg config.Config) *cobra.Command {
+	var flags struct {
+		DryRun bool
+	}
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the cached files of extensions that are not installed anymore",
+		Long:  "Remove the cached files of extensions that are not installed in the current or the global config. It runs automatically after extension remove.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			res, err := extensions.FindPrunable(knownOrigins(cfg))
+			if err != nil {
+				return err
+			}
+
+			if len(res.Dirs) == 0 && len(res.IndexEntries) == 0 {
+				cmd.Println("✅ Nothing to prune")
+				return nil
+			}
+
+			for _, dir := range res.Dirs {
+				cmd.Printf("%s\t%s\n", formatSize(dir.Size), dir.Path)
+			}
+
+			if len(res.IndexEntries) > 0 {
+				cmd.Printf("%d stale manifests in the index\n", len(res.IndexEntries))
+			}
+
+			if flags.DryRun {
+				cmd.Printf("\nRun without --dry-run to free %s\n", formatSize(res.Size()))
+				return nil
+			}
+
+			return pruneCache(cmd, cfg, true)
+		},
+	}
+
+	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "list the files that would be removed, without removing them")
+
+	return cmd
+}
+
+// pruneCache removes the cached files matching none of the known origins, and reports the size freed
+func pruneCache(cmd *cobra.Command, cfg config.Config, verbose bool) error {
+	res, err := extensions.FindPrunable(knownOrigins(cfg))
+	if err != nil {
+		return err
+	}
+
+	if err := res.Remove(); err != nil {
+		return err
+	}
+
+	if res.Size() > 0 || verbose {
+		cmd.Printf("✅ Freed %s\n", formatSize(res.Size()))
+	}
+
+	return nil
+}
+
+// knownOrigins returns the origins of the current and the global config, the only ones sunbeam can tell are in use.
+// Local origins are resolved from the directory of the config declaring them, as they are when the extensions are loaded.
+func knownOrigins(cfg config.Config) []string {
+	var origins []string
+	for _, extensionConfig := range cfg.Extensions {
+		origins = append(origins, resolveConfigOrigin(cfg, extensionConfig.Origin))
+	}
+
+	globalPath := filepath.Join(utils.ConfigDir(), "sunbeam.json")
+	if globalPath == cfg.Path() {
+		return origins
+	}
+
+	if globalConfig, err := config.Load(globalPath); err == nil {
+		for _, extensionConfig := range globalConfig.Extensions {
+			origins = append(origins, resolveConfigOrigin(globalConfig, extensionConfig.Origin))
+		}
+	}
+
+	return origins
+}
+
+func resolveConfigOrigin(cfg config.Config, origin string) string {
+	if extensions.IsRemote(origin) {
+		return origin
+	}
+
+	return cfg.Resolve(origin)
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
 }
 
 func NewCmdExtensionConfigure(cfg config.Config) *cobra.Command {
@@ -403,7 +1907,7 @@ func NewCmdExtensionConfigure(cfg config.Config) *cobra.Command {
 				return fmt.Errorf("extension %s not found", args[0])
 			}
 
-			extension, err := extensions.LoadExtension(extensionConfig.Origin)
+			extension, err := extensions.LoadExtension(cfg, args[0])
 			if err != nil {
 				return fmt.Errorf("failed to load extension: %w", err)
 			}
@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/history"
+	"github.com/pomdtr/sunbeam/internal/tui"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+const testCatalog = `{
+  "extensions": [
+    {"name": "hello", "origin": "./hello.sh", "manifest": {"title": "Hello", "description": "Say hello", "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}]}},
+    {"name": "github", "origin": "./github.sh", "manifest": {"title": "GitHub", "description": "Manage your repositories"}},
+    {"name": "gitlab", "origin": "./gitlab.sh", "manifest": {"title": "GitLab"}},
+    {"name": "devdocs", "origin": "https://example.com/devdocs.sh", "manifest": {"title": "DevDocs", "commands": [{"name": "search", "title": "Search GitHub Docs", "mode": "filter"}]}}
+  ]
+}`
+
+const helloScript = `#!/bin/sh
+echo '{"title": "Hello", "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}]}'
+`
+
+// isolateDirs moves the cache, the index and the global config to a temporary directory, which is returned
+func isolateDirs(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(root, "cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "xdg"))
+	indexPath := extensions.IndexPath
+	extensions.IndexPath = filepath.Join(root, "cache", "sunbeam", "extensions", "index.json")
+	t.Cleanup(func() {
+		extensions.IndexPath = indexPath
+	})
+
+	return root
+}
+
+// setupCatalog writes the catalog and the hello extension to a directory, and a config pointing to it to another one.
+// The cache and the index are moved to a temporary directory as well.
+func setupCatalog(t *testing.T, installed map[string]config.ExtensionConfig) (config.Config, string) {
+	t.Helper()
+
+	root := isolateDirs(t)
+	catalogDir := filepath.Join(root, "catalog")
+	if err := os.MkdirAll(catalogDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(catalogDir, "catalog.json"), []byte(testCatalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(catalogDir, "hello.sh"), []byte(helloScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configDir := filepath.Join(root, "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if installed == nil {
+		installed = make(map[string]config.ExtensionConfig)
+	}
+
+	bts, err := json.Marshal(config.Config{Catalog: "../catalog/catalog.json", Extensions: installed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(configDir, "sunbeam.json")
+	if err := os.WriteFile(configPath, bts, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cfg, catalogDir
+}
+
+func execute(t *testing.T, cmd *cobra.Command, args ...string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestExtensionSearch(t *testing.T) {
+	cfg, catalogDir := setupCatalog(t, nil)
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "", expected: []string{"devdocs", "github", "gitlab", "hello"}},
+		{query: "git", expected: []string{"github", "gitlab", "devdocs"}},
+		{query: "github", expected: []string{"github", "devdocs"}},
+		{query: "zzz", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			out, err := execute(t, NewCmdExtensionSearch(cfg), tc.query, "--json")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var entries []extensions.CatalogEntry
+			if err := json.Unmarshal([]byte(out), &entries); err != nil {
+				t.Fatalf("failed to decode %s: %v", out, err)
+			}
+
+			names := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+			}
+
+			if strings.Join(names, ",") != strings.Join(tc.expected, ",") {
+				t.Fatalf("expected %v, got %v", tc.expected, names)
+			}
+
+			for _, entry := range entries {
+				if entry.Name == "hello" && entry.Origin != filepath.Join(catalogDir, "hello.sh") {
+					t.Errorf("expected the origin to be resolved from the catalog, got %s", entry.Origin)
+				}
+			}
+		})
+	}
+}
+
+func TestExtensionSearchInstall(t *testing.T) {
+	cfg, catalogDir := setupCatalog(t, nil)
+
+	// the catalog is neither in the working directory nor in the config directory
+	if _, err := execute(t, NewCmdExtensionSearch(cfg), "hello", "--install", "--yes"); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := config.Load(cfg.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extensionConfig, ok := saved.Extensions["hello"]
+	if !ok {
+		t.Fatalf("expected the best match to be installed")
+	}
+
+	if extensionConfig.Origin != filepath.Join(catalogDir, "hello.sh") {
+		t.Errorf("expected the origin to be resolved from the catalog, got %s", extensionConfig.Origin)
+	}
+
+	if _, err := extensions.LoadExtension(saved, "hello"); err != nil {
+		t.Errorf("expected the installed extension to load: %v", err)
+	}
+
+	if _, err := execute(t, NewCmdExtensionSearch(saved), "zzz", "--install", "--yes"); err == nil {
+		t.Errorf("expected an error when nothing matches")
+	}
+}
+
+func TestExtensionBrowse(t *testing.T) {
+	cfg, catalogDir := setupCatalog(t, map[string]config.ExtensionConfig{
+		// installed from the config directory, with a path relative to it
+		"hello": {Origin: "../catalog/hello.sh"},
+	})
+
+	page, err := newCatalogPage(cfg, history.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tui.RunKeyScript(page, "type hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(result.Screen, "Hello") || !strings.Contains(result.Screen, "Installed") {
+		t.Errorf("expected the installed entry to be flagged:\n%s", result.Screen)
+	}
+
+	page, err = newCatalogPage(cfg, history.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err = tui.RunKeyScript(page, "type gitlab, enter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Actions) != 1 || result.Actions[0].Exec == nil {
+		t.Fatalf("expected the install action to run, got %v", result.Actions)
+	}
+
+	command := result.Actions[0].Exec.Command
+	if !strings.Contains(command, shellQuote(filepath.Join(catalogDir, "gitlab.sh"))) {
+		t.Errorf("expected the install command to use the origin resolved from the catalog, got %s", command)
+	}
+}
+
+func TestExtensionBrowseNoCatalog(t *testing.T) {
+	if _, err := newCatalogPage(config.Config{}, history.New()); err == nil {
+		t.Errorf("expected browse to require a catalog")
+	}
+}
+
+// setupPrune installs a local extension with a path relative to the config, and creates its cache directory next to the one of an uninstalled extension
+func setupPrune(t *testing.T) (cfg config.Config, installed string, orphan string) {
+	t.Helper()
+
+	root := isolateDirs(t)
+	if err := os.WriteFile(filepath.Join(root, "hello.sh"), []byte(helloScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	bts, err := json.Marshal(config.Config{Extensions: map[string]config.ExtensionConfig{"hello": {Origin: "./hello.sh"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "sunbeam.json"), bts, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err = config.Load(filepath.Join(root, "sunbeam.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, errs := extensions.LoadExtensions(cfg); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	installed, err = extensions.ExtensionDir(filepath.Join(root, "hello.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := extensions.Hash("https://example.com/orphan.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orphan = filepath.Join(utils.CacheDir(), "extensions", hash)
+	for _, dir := range []string{installed, orphan} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "manifest.json"), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cfg, installed, orphan
+}
+
+func TestExtensionPrune(t *testing.T) {
+	cfg, installed, orphan := setupPrune(t)
+
+	out, err := execute(t, NewCmdExtensionPrune(cfg), "--dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, orphan) || strings.Contains(out, installed) {
+		t.Errorf("expected only the orphan to be listed, got:\n%s", out)
+	}
+
+	if _, err := os.Stat(orphan); err != nil {
+		t.Fatalf("expected a dry run not to remove anything")
+	}
+
+	out, err = execute(t, NewCmdExtensionPrune(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "Freed") {
+		t.Errorf("expected the freed size to be reported, got:\n%s", out)
+	}
+
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", orphan)
+	}
+
+	// the origin is relative to the config, it must be resolved the same way as when the extension is loaded
+	if _, err := os.Stat(installed); err != nil {
+		t.Errorf("expected the cache of the installed extension to be kept")
+	}
+
+	out, err = execute(t, NewCmdExtensionPrune(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "Nothing to prune") {
+		t.Errorf("expected nothing left to prune, got:\n%s", out)
+	}
+}
+
+func TestExtensionRemovePrunes(t *testing.T) {
+	cfg, installed, orphan := setupPrune(t)
+
+	if _, err := execute(t, NewCmdExtensionRemove(cfg), "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dir := range []string{installed, orphan} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be pruned after remove", dir)
+		}
+	}
+
+	saved, err := config.Load(cfg.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := saved.Extensions["hello"]; ok {
+		t.Errorf("expected the extension to be removed from the config")
+	}
+}
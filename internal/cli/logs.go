@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/spf13/cobra"
+)
+
+// LogsPollInterval is the delay between two reads of the logs in follow mode
+const LogsPollInterval = 500 * time.Millisecond
+
+func NewCmdLogs(cfg config.Config) *cobra.Command {
+	flags := struct {
+		Follow bool
+		Lines  int
+		JSON   bool
+	}{}
+
+	cmd := &cobra.Command{
+		Use:     "logs [alias]",
+		Short:   "Show the logs of extension runs",
+		GroupID: CommandGroupCore,
+		Args:    cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return sortedAliases(cfg), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var alias string
+			if len(args) > 0 {
+				alias = args[0]
+			}
+
+			// the follower starts right after the runs read, none are missed or printed twice
+			follower, entries, err := extensions.FollowLogs(alias)
+			if err != nil {
+				return fmt.Errorf("failed to read logs: %w", err)
+			}
+
+			// the logs of removed extensions are kept until they are rotated away
+			if _, ok := cfg.Extensions[alias]; alias != "" && !ok && len(entries) == 0 {
+				return fmt.Errorf("no logs found for extension %s", alias)
+			}
+
+			if flags.Lines > 0 && len(entries) > flags.Lines {
+				entries = entries[len(entries)-flags.Lines:]
+			}
+
+			for _, entry := range entries {
+				if err := printLogEntry(cmd.OutOrStdout(), entry, flags.JSON); err != nil {
+					return err
+				}
+			}
+
+			if !flags.Follow {
+				return nil
+			}
+
+			for range time.Tick(LogsPollInterval) {
+				entries, err := follower.Next()
+				if err != nil {
+					return fmt.Errorf("failed to read logs: %w", err)
+				}
+
+				for _, entry := range entries {
+					if err := printLogEntry(cmd.OutOrStdout(), entry, flags.JSON); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&flags.Follow, "follow", "f", false, "wait for new runs and print them")
+	cmd.Flags().IntVarP(&flags.Lines, "lines", "n", 20, "number of runs to show, 0 to show all")
+	cmd.Flags().BoolVar(&flags.JSON, "json", false, "output the runs as json lines")
+
+	return cmd
+}
+
+func printLogEntry(w io.Writer, entry extensions.LogEntry, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(entry)
+	}
+
+	status := "✅"
+	if entry.ExitCode != 0 {
+		status = "❌"
+	}
+
+	if _, err := fmt.Fprintf(w, "%s %s %s %s (exit %d, %s)\n", status, entry.Time.Local().Format(time.DateTime), entry.Alias, entry.Command, entry.ExitCode, entry.Duration.Round(time.Millisecond)); err != nil {
+		return err
+	}
+
+	stderr := strings.TrimRight(entry.Stderr, "\n")
+	if stderr == "" {
+		return nil
+	}
+
+	for _, line := range strings.Split(stderr, "\n") {
+		if _, err := fmt.Fprintf(w, "    %s\n", line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
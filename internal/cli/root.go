@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/MakeNowJust/heredoc"
@@ -14,7 +15,6 @@ import (
 	"github.com/pomdtr/sunbeam/internal/extensions"
 	"github.com/pomdtr/sunbeam/internal/history"
 	"github.com/pomdtr/sunbeam/internal/tui"
-	"github.com/pomdtr/sunbeam/internal/utils"
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
@@ -33,6 +33,22 @@ func IsSunbeamRunning() bool {
 	return len(os.Getenv("SUNBEAM")) > 0
 }
 
+// addGlobalFlags declares the flags shared by the root command and the extension commands.
+// Extension commands are registered lazily and parse their own flags, so they must declare them as well.
+func addGlobalFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&extensions.Debug, "debug", false, fmt.Sprintf("record extension invocations, press %s to inspect them", tui.Keys.Help(tui.KeyInspector)))
+	cmd.PersistentFlags().StringVar(&extensions.RecordPath, "record", "", "append the runs of extensions to a fixture file")
+	cmd.PersistentFlags().StringVar(&extensions.ReplayPath, "replay", "", "serve the runs of extensions from a fixture file instead of running them")
+	cmd.MarkFlagsMutuallyExclusive("record", "replay")
+	cmd.PersistentFlags().StringVar(&tui.KeyScript, "key-script", "", "drive the pages with a key script, and print the final screen as json")
+	_ = cmd.PersistentFlags().MarkHidden("key-script")
+}
+
+// isInteractive reports whether pages should be drawn, either in the terminal or by a key script
+func isInteractive() bool {
+	return tui.KeyScript != "" || isatty.IsTerminal(os.Stdout.Fd())
+}
+
 //go:embed embed/sunbeam.json
 var configBytes []byte
 
@@ -48,6 +64,8 @@ func NewRootCmd() (*cobra.Command, error) {
 See https://pomdtr.github.io/sunbeam for more information.`,
 	}
 
+	addGlobalFlags(rootCmd)
+
 	rootCmd.AddGroup(&cobra.Group{
 		ID:    CommandGroupCore,
 		Title: "Core Commands:",
@@ -137,26 +155,25 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 	if err != nil {
 		return nil, err
 	}
+
+	if err := tui.SetKeymap(cfg.Keybindings); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 	rootCmd.AddCommand(NewCmdExtension(cfg))
+	rootCmd.AddCommand(NewCmdLogs(cfg))
 
-	extensionMap := make(map[string]extensions.Extension)
-	for alias, extensionConfig := range cfg.Extensions {
-		extension, err := extensions.LoadExtension(extensionConfig.Origin)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error loading extension %s: %s\n", alias, err)
-			continue
-		}
-		extensionMap[alias] = extension
+	// extensions are only loaded when their command is invoked, so that a broken extension does not affect unrelated commands
+	index, err := extensions.LoadIndex(extensions.IndexPath)
+	if err != nil {
+		return nil, err
+	}
 
-		command, err := NewCmdCustom(alias, extension, extensionConfig)
-		if err != nil {
-			return nil, err
-		}
-		rootCmd.AddCommand(command)
+	for _, alias := range sortedAliases(cfg) {
+		rootCmd.AddCommand(NewCmdLazyCustom(cfg, alias, index))
 	}
 
 	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if !isatty.IsTerminal(os.Stdout.Fd()) {
+		if !isInteractive() {
 			encoder := json.NewEncoder(os.Stdout)
 			encoder.SetIndent("", "  ")
 			encoder.SetEscapeHTML(false)
@@ -175,16 +192,15 @@ See https://pomdtr.github.io/sunbeam for more information.`,
 			}
 
 			var items []sunbeam.ListItem
-			items = append(items, onelinerListItems(cfg.Oneliners)...)
+			items = append(items, tui.OnelinerListItems(cfg.Oneliners)...)
 
-			extensionMap := make(map[string]extensions.Extension)
-			for alias, extensionConfig := range cfg.Extensions {
-				extension, err := extensions.LoadExtension(extensionConfig.Origin)
-				if err != nil {
+			extensionMap, _ := extensions.LoadExtensions(cfg)
+			for _, alias := range sortedAliases(cfg) {
+				extension, ok := extensionMap[alias]
+				if !ok {
 					continue
 				}
-				extensionMap[alias] = extension
-				items = append(items, extensionListItems(alias, extension, extensionConfig)...)
+				items = append(items, tui.ExtensionListItems(alias, extension, cfg.Extensions[alias])...)
 			}
 
 			return cfg, items, nil
@@ -231,105 +247,8 @@ func buildDoc(command *cobra.Command) (string, error) {
 	return out.String(), nil
 }
 
-func onelinerListItems(oneliners []config.Oneliner) []sunbeam.ListItem {
-	var items []sunbeam.ListItem
-	for _, oneliner := range oneliners {
-		item := sunbeam.ListItem{
-			Id:          fmt.Sprintf("oneliner - %s", oneliner.Title),
-			Title:       oneliner.Title,
-			Accessories: []string{"Oneliner"},
-			Actions: []sunbeam.Action{
-				{
-					Title: "Run",
-					Type:  sunbeam.ActionTypeExec,
-					Exec: &sunbeam.ExecAction{
-						Command:     oneliner.Command,
-						Interactive: oneliner.Interactive,
-						Dir:         oneliner.Cwd,
-						Exit:        oneliner.Exit,
-					},
-				},
-				{
-					Title: "Copy Command",
-					Key:   "c",
-					Type:  sunbeam.ActionTypeCopy,
-					Copy: &sunbeam.CopyAction{
-						Text: oneliner.Command,
-					},
-				},
-			},
-		}
-
-		items = append(items, item)
-	}
-
-	return items
-}
-
-func extensionListItems(alias string, extension extensions.Extension, extensionConfig config.ExtensionConfig) []sunbeam.ListItem {
-	var items []sunbeam.ListItem
-
-	for _, rootItem := range extensionConfig.Root {
-		items = append(items, sunbeam.ListItem{
-			Id:          fmt.Sprintf("%s - %s", alias, rootItem.Title),
-			Title:       rootItem.Title,
-			Subtitle:    extension.Manifest.Title,
-			Accessories: []string{"Command"},
-			Actions: []sunbeam.Action{
-				{
-					Title: "Run",
-					Type:  sunbeam.ActionTypeRun,
-					Run:   &sunbeam.RunAction{Extension: alias, Command: rootItem.Command, Params: rootItem.Params},
-				},
-			},
-		})
-	}
-
-	for _, command := range extension.RootCommands() {
-		item := sunbeam.ListItem{
-			Id:          fmt.Sprintf("%s - %s", alias, command.Name),
-			Title:       command.Title,
-			Subtitle:    extension.Manifest.Title,
-			Accessories: []string{"Command"},
-			Actions: []sunbeam.Action{
-				{
-					Title: "Run",
-					Type:  sunbeam.ActionTypeRun,
-					Run:   &sunbeam.RunAction{Extension: alias, Command: command.Name},
-				},
-			},
-		}
-
-		if !extensions.IsRemote(extensionConfig.Origin) {
-			item.Actions = append(item.Actions, sunbeam.Action{
-				Title: "Edit Extension",
-				Key:   "e",
-				Type:  sunbeam.ActionTypeEdit,
-				Edit: &sunbeam.EditAction{
-					Path:   extension.Entrypoint,
-					Reload: true,
-				},
-			})
-		} else {
-			item.Actions = append(item.Actions, sunbeam.Action{
-				Title: "View Source",
-				Key:   "c",
-				Type:  sunbeam.ActionTypeExec,
-				Exec:  &sunbeam.ExecAction{Command: fmt.Sprintf("curl %s | %s", extensionConfig.Origin, utils.FindPager()), Interactive: true},
-			})
-		}
-
-		if len(extension.Manifest.Preferences) > 0 {
-			item.Actions = append(item.Actions, sunbeam.Action{
-				Title:  "Configure Extension",
-				Key:    "s",
-				Type:   sunbeam.ActionTypeConfig,
-				Config: &sunbeam.ConfigAction{Extension: alias},
-			})
-		}
-
-		items = append(items, item)
-	}
-
-	return items
+func sortedAliases(cfg config.Config) []string {
+	aliases := cfg.Aliases()
+	sort.Strings(aliases)
+	return aliases
 }
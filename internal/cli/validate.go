@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -8,6 +9,7 @@ import (
 	"github.com/mattn/go-isatty"
 	"github.com/pomdtr/sunbeam/internal/config"
 	"github.com/pomdtr/sunbeam/internal/schemas"
+	"github.com/pomdtr/sunbeam/internal/tui"
 	"github.com/spf13/cobra"
 )
 
@@ -142,6 +144,15 @@ func NewCmdValidateConfig() *cobra.Command {
 				return fmt.Errorf("config is invalid: %s", err)
 			}
 
+			var cfg config.Config
+			if err := json.Unmarshal(inputBytes, &cfg); err != nil {
+				return fmt.Errorf("config is invalid: %s", err)
+			}
+
+			if _, err := tui.LoadKeymap(cfg.Keybindings); err != nil {
+				return fmt.Errorf("config is invalid: %s", err)
+			}
+
 			fmt.Println("✅ Config is valid!")
 			return nil
 		},
@@ -9,6 +9,7 @@ import (
 
 	"github.com/pomdtr/sunbeam/internal/schemas"
 	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
 var Path string
@@ -36,18 +37,58 @@ func init() {
 }
 
 type Config struct {
-	Oneliners  []Oneliner                 `json:"oneliners,omitempty"`
-	Extensions map[string]ExtensionConfig `json:"extensions,omitempty"`
-	path       string                     `json:"-"`
+	Oneliners   []Oneliner                 `json:"oneliners,omitempty"`
+	Extensions  map[string]ExtensionConfig `json:"extensions,omitempty"`
+	Timeouts    *Timeouts                  `json:"timeouts,omitempty"`
+	Catalog     string                     `json:"catalog,omitempty"`
+	Keybindings map[string][]string        `json:"keybindings,omitempty"`
+	// KeepVersions is the number of versions kept for each remote extension, a zero value means the default is used
+	KeepVersions int `json:"keepVersions,omitempty"`
+
+	path     string `json:"-"`
+	stateDir string `json:"-"`
 }
 
+// New returns an empty config built in memory, it is not saved to a file.
+// Relative paths are resolved from stateDir, and the files sunbeam keeps next to a config, like the lockfile, are written there instead of next to the global config.
+func New(stateDir string) Config {
+	return Config{
+		Extensions: make(map[string]ExtensionConfig),
+		stateDir:   stateDir,
+	}
+}
+
+// Path returns the file the config was loaded from, a file of the state directory for configs built with New, or the global config path
+func (cfg Config) Path() string {
+	if cfg.path != "" {
+		return cfg.path
+	}
+
+	if cfg.stateDir != "" {
+		return filepath.Join(cfg.stateDir, "sunbeam.json")
+	}
+
+	return Path
+}
+
+// StateDir returns the state directory of configs built with New, it is empty otherwise
+func (cfg Config) StateDir() string {
+	return cfg.stateDir
+}
+
+// Loaded reports whether the config was loaded from a file, configs built in memory can not be saved
+func (cfg Config) Loaded() bool {
+	return cfg.path != ""
+}
+
+// Resolve expands the home directory, and resolves relative paths from the directory of the config
 func (cfg Config) Resolve(path string) string {
 	if strings.HasPrefix(path, "~/") {
 		return filepath.Join(os.Getenv("HOME"), path[2:])
 	}
 
 	if !filepath.IsAbs(path) {
-		return filepath.Join(filepath.Dir(cfg.path), path)
+		return filepath.Join(filepath.Dir(cfg.Path()), path)
 	}
 
 	return path
@@ -57,6 +98,48 @@ type ExtensionConfig struct {
 	Origin      string         `json:"origin,omitempty"`
 	Preferences map[string]any `json:"preferences,omitempty"`
 	Root        []RootItem     `json:"root,omitempty"`
+	Timeouts    *Timeouts      `json:"timeouts,omitempty"`
+	Env         *EnvConfig     `json:"env,omitempty"`
+
+	// Manifest is only set by programs embedding sunbeam, the entrypoint is not run to extract it then
+	Manifest *sunbeam.Manifest `json:"-"`
+}
+
+// EnvConfig controls which environment variables are passed to an extension
+type EnvConfig struct {
+	Allow []string          `json:"allow,omitempty"`
+	Deny  []string          `json:"deny,omitempty"`
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// Timeouts are expressed in seconds, a zero value means the default is used.
+// Runs have no default, only the commands whose output is captured are bounded then.
+type Timeouts struct {
+	Manifest int `json:"manifest,omitempty"`
+	Run      int `json:"run,omitempty"`
+}
+
+// ExtensionTimeouts merges the timeouts of an extension with the global ones
+func (cfg Config) ExtensionTimeouts(alias string) Timeouts {
+	var timeouts Timeouts
+	if cfg.Timeouts != nil {
+		timeouts = *cfg.Timeouts
+	}
+
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok || extensionConfig.Timeouts == nil {
+		return timeouts
+	}
+
+	if extensionConfig.Timeouts.Manifest > 0 {
+		timeouts.Manifest = extensionConfig.Timeouts.Manifest
+	}
+
+	if extensionConfig.Timeouts.Run > 0 {
+		timeouts.Run = extensionConfig.Timeouts.Run
+	}
+
+	return timeouts
 }
 
 type RootItem struct {
@@ -105,7 +188,12 @@ func Load(configPath string) (Config, error) {
 	return config, nil
 }
 
+// Save writes the config to the file it was loaded from
 func (c Config) Save() error {
+	if c.path == "" {
+		return fmt.Errorf("failed to save config: it was not loaded from a file")
+	}
+
 	f, err := os.Create(c.path)
 	if err != nil {
 		return fmt.Errorf("failed to open config: %w", err)
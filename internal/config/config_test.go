@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveInMemory(t *testing.T) {
+	cfg := Config{Extensions: make(map[string]ExtensionConfig)}
+	if cfg.Loaded() {
+		t.Fatalf("expected a config built in memory not to be loaded")
+	}
+
+	if err := cfg.Save(); err == nil {
+		t.Errorf("expected saving a config built in memory to fail")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "sunbeam.json")
+	if err := os.WriteFile(configPath, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resolved := cfg.Resolve("hello.sh"); resolved != filepath.Join(dir, "hello.sh") {
+		t.Errorf("expected relative paths to be resolved from the config directory, got %s", resolved)
+	}
+
+	if resolved := cfg.Resolve("/bin/hello.sh"); resolved != "/bin/hello.sh" {
+		t.Errorf("expected absolute paths to be kept, got %s", resolved)
+	}
+}
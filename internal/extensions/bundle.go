@@ -0,0 +1,549 @@
+package extensions
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// BundleDescriptor is the file at the root of a bundle, pointing to its entrypoint
+const BundleDescriptor = "sunbeam-extension.json"
+
+var (
+	// MaxBundleSize is the total size of the files unpacked from a bundle, in bytes
+	MaxBundleSize int64 = 256 << 20
+	// MaxBundleEntries is the number of files and directories unpacked from a bundle
+	MaxBundleEntries = 10_000
+)
+
+// Bundle is the content of the descriptor of a bundle.
+// Bundles are .tar.gz or .zip archives, allowing extensions to ship multiple files.
+type Bundle struct {
+	Entrypoint string `json:"entrypoint"`
+}
+
+func IsBundle(origin string) bool {
+	if IsGit(origin) {
+		return false
+	}
+
+	name := origin
+	if IsRemote(origin) {
+		originUrl, err := url.Parse(origin)
+		if err != nil {
+			return false
+		}
+		name = originUrl.Path
+	}
+
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".zip")
+}
+
+// bundleArchive returns the path of the archive of a bundle: the origin itself for local bundles, a copy in the extension dir for remote ones
+func bundleArchive(origin string, extensionDir string) (string, error) {
+	if !IsRemote(origin) {
+		return ResolveLocalPath(origin)
+	}
+
+	originUrl, err := url.Parse(origin)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse origin: %w", err)
+	}
+
+	return filepath.Join(extensionDir, filepath.Base(originUrl.Path)), nil
+}
+
+// resolveBundleEntrypoint reads the descriptor of an unpacked bundle
+func resolveBundleEntrypoint(bundleDir string) (string, error) {
+	bts, err := os.ReadFile(filepath.Join(bundleDir, BundleDescriptor))
+	if err != nil {
+		return "", fmt.Errorf("failed to read bundle descriptor: %w", err)
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(bts, &bundle); err != nil {
+		return "", fmt.Errorf("failed to decode bundle descriptor: %w", err)
+	}
+
+	if bundle.Entrypoint == "" {
+		return "", fmt.Errorf("bundle descriptor has no entrypoint")
+	}
+
+	return safeJoin(bundleDir, bundle.Entrypoint)
+}
+
+// loadBundleEntrypoint unpacks the bundle if needed, the cache validators are only set if the archive was just downloaded.
+// Remote bundles are downloaded once and verified against the lockfile, local ones are unpacked again whenever the archive is modified.
+func loadBundleEntrypoint(origin string, extensionDir string, lockfile *Lockfile) (string, CacheValidators, error) {
+	archive, err := bundleArchive(origin, extensionDir)
+	if err != nil {
+		return "", CacheValidators{}, err
+	}
+	bundleDir := filepath.Join(extensionDir, "bundle")
+
+	if !IsRemote(origin) {
+		archiveInfo, err := os.Stat(archive)
+		if err != nil {
+			return "", CacheValidators{}, fmt.Errorf("failed to find bundle: %w", err)
+		}
+
+		if bundleInfo, err := os.Stat(bundleDir); err == nil && bundleInfo.ModTime().Equal(archiveInfo.ModTime()) {
+			entrypoint, err := resolveBundleEntrypoint(bundleDir)
+			return entrypoint, CacheValidators{}, err
+		}
+
+		if err := os.MkdirAll(extensionDir, 0755); err != nil {
+			return "", CacheValidators{}, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		entrypoint, err := UnpackBundle(archive, bundleDir)
+		if err != nil {
+			return "", CacheValidators{}, err
+		}
+
+		if err := os.Chtimes(bundleDir, archiveInfo.ModTime(), archiveInfo.ModTime()); err != nil {
+			return "", CacheValidators{}, err
+		}
+
+		return entrypoint, CacheValidators{}, nil
+	}
+
+	if _, err := os.Stat(archive); err == nil {
+		if err := lockfile.Verify(origin, archive); err != nil {
+			return "", CacheValidators{}, err
+		}
+
+		if _, err := os.Stat(bundleDir); err == nil {
+			entrypoint, err := resolveBundleEntrypoint(bundleDir)
+			return entrypoint, CacheValidators{}, err
+		}
+
+		entrypoint, err := UnpackBundle(archive, bundleDir)
+		return entrypoint, CacheValidators{}, err
+	}
+
+	if err := lockfile.CheckPinned(origin); err != nil {
+		return "", CacheValidators{}, err
+	}
+
+	if err := os.MkdirAll(extensionDir, 0755); err != nil {
+		return "", CacheValidators{}, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	validators, err := DownloadEntrypoint(origin, archive)
+	if err != nil {
+		return "", CacheValidators{}, err
+	}
+
+	if err := lockfile.Verify(origin, archive); err != nil {
+		os.Remove(archive)
+		return "", CacheValidators{}, err
+	}
+
+	entrypoint, err := UnpackBundle(archive, bundleDir)
+	if err != nil {
+		return "", CacheValidators{}, err
+	}
+
+	return entrypoint, validators, nil
+}
+
+// UnpackBundle extracts an archive to dir, replacing its content, and returns the path of the entrypoint.
+// Entries that would be written outside of dir, links and special files are rejected, as well as bundles exceeding MaxBundleSize or MaxBundleEntries.
+func UnpackBundle(archive string, dir string) (string, error) {
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	var err error
+	if strings.HasSuffix(archive, ".zip") {
+		err = unpackZip(archive, tmp)
+	} else {
+		err = unpackTar(archive, tmp)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to unpack bundle: %w", err)
+	}
+
+	entrypoint, err := resolveBundleEntrypoint(tmp)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(entrypoint); err != nil {
+		return "", fmt.Errorf("entrypoint %s not found in bundle", filepath.Base(entrypoint))
+	}
+
+	if err := os.Chmod(entrypoint, 0755); err != nil {
+		return "", fmt.Errorf("failed to chmod entrypoint: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		return "", fmt.Errorf("failed to unpack bundle: %w", err)
+	}
+
+	return resolveBundleEntrypoint(dir)
+}
+
+func unpackTar(archive string, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	var limits unpackLimits
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := limits.entry(); err != nil {
+			return err
+		}
+
+		target, err := safeJoin(dir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := limits.writeFile(target, reader, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry %s: only files and directories are allowed", header.Name)
+		}
+	}
+}
+
+func unpackZip(archive string, dir string) error {
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var limits unpackLimits
+	for _, file := range reader.File {
+		if err := limits.entry(); err != nil {
+			return err
+		}
+
+		target, err := safeJoin(dir, file.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := file.FileInfo().Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := file.Open()
+			if err != nil {
+				return err
+			}
+
+			err = limits.writeFile(target, rc, mode)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry %s: only files and directories are allowed", file.Name)
+		}
+	}
+
+	return nil
+}
+
+// unpackLimits counts what was unpacked from a bundle, so that a bundle bomb can not fill the cache
+type unpackLimits struct {
+	size    int64
+	entries int
+}
+
+func (l *unpackLimits) entry() error {
+	l.entries++
+	if l.entries > MaxBundleEntries {
+		return fmt.Errorf("bundle has more than %d entries", MaxBundleEntries)
+	}
+
+	return nil
+}
+
+// writeFile copies the file, the declared size of entries is not trusted
+func (l *unpackLimits) writeFile(target string, r io.Reader, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+
+	n, err := io.Copy(f, io.LimitReader(r, MaxBundleSize-l.size+1))
+	l.size += n
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	if l.size > MaxBundleSize {
+		f.Close()
+		return fmt.Errorf("bundle is larger than %d bytes once unpacked", MaxBundleSize)
+	}
+
+	return f.Close()
+}
+
+// safeJoin joins a relative path to dir, refusing paths that escape it
+func safeJoin(dir string, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("illegal path %s: absolute paths are not allowed", name)
+	}
+
+	target := filepath.Join(dir, name)
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("illegal path %s: outside of the bundle", name)
+	}
+
+	return target, nil
+}
+
+// PackBundle writes the content of dir to an archive, the format is inferred from the extension of the target.
+// The directory must contain a descriptor pointing to an existing entrypoint.
+func PackBundle(dir string, target string) error {
+	entrypoint, err := resolveBundleEntrypoint(dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(entrypoint); err != nil {
+		return fmt.Errorf("entrypoint %s not found", entrypoint)
+	}
+
+	f, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(target, ".zip") {
+		err = packZip(dir, target, f)
+	} else if strings.HasSuffix(target, ".tar.gz") || strings.HasSuffix(target, ".tgz") {
+		err = packTar(dir, target, f)
+	} else {
+		err = fmt.Errorf("unsupported bundle format: %s, use .tar.gz, .tgz or .zip", filepath.Base(target))
+	}
+
+	if err != nil {
+		f.Close()
+		os.Remove(target)
+		return err
+	}
+
+	return f.Close()
+}
+
+// walkBundle calls fn for every file of the bundle, skipping the vcs directories and the bundle being written
+func walkBundle(dir string, target string, fn func(path string, rel string, info fs.FileInfo) error) error {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		if info.IsDir() && (info.Name() == ".git" || info.Name() == ".hg") {
+			return filepath.SkipDir
+		}
+
+		if abs, err := filepath.Abs(path); err == nil && abs == absTarget {
+			return nil
+		}
+
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return fmt.Errorf("unsupported file %s: only files and directories are allowed", rel)
+		}
+
+		return fn(path, filepath.ToSlash(rel), info)
+	})
+}
+
+func packTar(dir string, target string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	writer := tar.NewWriter(gz)
+
+	if err := walkBundle(dir, target, func(path string, rel string, info fs.FileInfo) error {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		return copyInto(writer, path)
+	}); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+func packZip(dir string, target string, w io.Writer) error {
+	writer := zip.NewWriter(w)
+
+	if err := walkBundle(dir, target, func(path string, rel string, info fs.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		fw, err := writer.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		return copyInto(fw, path)
+	}); err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func copyInto(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// diffBundles returns a unified diff of every file that differs between two unpacked bundles
+func diffBundles(oldDir string, newDir string) (string, error) {
+	files := make(map[string]bool)
+	for _, dir := range []string{oldDir, newDir} {
+		err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			} else if err != nil {
+				return err
+			}
+
+			if info.Mode().IsRegular() {
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+				files[filepath.ToSlash(rel)] = true
+			}
+
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diff strings.Builder
+	for _, name := range names {
+		oldBytes, err := os.ReadFile(filepath.Join(oldDir, filepath.FromSlash(name)))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+
+		newBytes, err := os.ReadFile(filepath.Join(newDir, filepath.FromSlash(name)))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+
+		diff.WriteString(udiff.Unified("a/"+name, "b/"+name, string(oldBytes), string(newBytes)))
+	}
+
+	return diff.String(), nil
+}
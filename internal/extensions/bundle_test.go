@@ -0,0 +1,339 @@
+package extensions
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type bundleEntry struct {
+	name     string
+	body     string
+	typeflag byte
+	linkname string
+}
+
+const bundleDescriptor = `{"entrypoint": "main.sh"}`
+
+func writeTarBundle(tb testing.TB, path string, entries []bundleEntry) {
+	tb.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	writer := tar.NewWriter(gz)
+	for _, entry := range entries {
+		typeflag := entry.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+
+		header := &tar.Header{Name: entry.name, Typeflag: typeflag, Linkname: entry.linkname, Mode: 0644, Size: int64(len(entry.body))}
+		if typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+
+		if err := writer.WriteHeader(header); err != nil {
+			tb.Fatal(err)
+		}
+
+		if typeflag == tar.TypeReg {
+			if _, err := writer.Write([]byte(entry.body)); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		tb.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func writeZipBundle(tb testing.TB, path string, entries []bundleEntry) {
+	tb.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+
+	writer := zip.NewWriter(f)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		body := entry.body
+		switch entry.typeflag {
+		case tar.TypeSymlink:
+			header.SetMode(fs.ModeSymlink | 0777)
+			body = entry.linkname
+		default:
+			header.SetMode(0644)
+		}
+
+		w, err := writer.CreateHeader(header)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		if _, err := w.Write([]byte(body)); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// strayFiles lists the files left in root, except the archive itself
+func strayFiles(tb testing.TB, root string, archive string) []string {
+	tb.Helper()
+
+	var files []string
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || path == archive {
+			return nil
+		}
+
+		files = append(files, path)
+		return nil
+	}); err != nil {
+		tb.Fatal(err)
+	}
+
+	return files
+}
+
+func TestUnpackBundle(t *testing.T) {
+	for _, ext := range []string{".tar.gz", ".zip"} {
+		t.Run(ext, func(t *testing.T) {
+			root := t.TempDir()
+			archive := filepath.Join(root, "bundle"+ext)
+			entries := []bundleEntry{
+				{name: BundleDescriptor, body: bundleDescriptor},
+				{name: "main.sh", body: "#!/bin/sh\necho hi\n"},
+				{name: "lib/helper.sh", body: "echo helper\n"},
+			}
+			if ext == ".zip" {
+				writeZipBundle(t, archive, entries)
+			} else {
+				writeTarBundle(t, archive, entries)
+			}
+
+			dir := filepath.Join(root, "dest", "bundle")
+			entrypoint, err := UnpackBundle(archive, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if entrypoint != filepath.Join(dir, "main.sh") {
+				t.Errorf("expected the entrypoint to be main.sh, got %s", entrypoint)
+			}
+
+			if info, err := os.Stat(entrypoint); err != nil || info.Mode().Perm() != 0755 {
+				t.Errorf("expected the entrypoint to be executable: %v", err)
+			}
+
+			if bts, err := os.ReadFile(filepath.Join(dir, "lib", "helper.sh")); err != nil || string(bts) != "echo helper\n" {
+				t.Errorf("expected nested files to be unpacked: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnpackBundleRejected(t *testing.T) {
+	outside := t.TempDir()
+	absolute := filepath.Join(outside, "x")
+
+	testCases := []struct {
+		name    string
+		entries []bundleEntry
+		formats []string
+	}{
+		{
+			name:    "parent directory",
+			entries: []bundleEntry{{name: "../x", body: "evil"}},
+			formats: []string{".tar.gz", ".zip"},
+		},
+		{
+			name:    "nested parent directory",
+			entries: []bundleEntry{{name: "lib/../../../x", body: "evil"}},
+			formats: []string{".tar.gz", ".zip"},
+		},
+		{
+			name:    "absolute path",
+			entries: []bundleEntry{{name: absolute, body: "evil"}},
+			formats: []string{".tar.gz", ".zip"},
+		},
+		{
+			name:    "symlink",
+			entries: []bundleEntry{{name: "link", typeflag: tar.TypeSymlink, linkname: absolute}},
+			formats: []string{".tar.gz", ".zip"},
+		},
+		{
+			name:    "hardlink",
+			entries: []bundleEntry{{name: "link", typeflag: tar.TypeLink, linkname: absolute}},
+			formats: []string{".tar.gz"},
+		},
+		{
+			name: "write through symlink",
+			entries: []bundleEntry{
+				{name: "link", typeflag: tar.TypeSymlink, linkname: outside},
+				{name: "link/x", body: "evil"},
+			},
+			formats: []string{".tar.gz", ".zip"},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, ext := range tc.formats {
+			t.Run(tc.name+ext, func(t *testing.T) {
+				root := t.TempDir()
+				archive := filepath.Join(root, "bundle"+ext)
+				entries := append([]bundleEntry{
+					{name: BundleDescriptor, body: bundleDescriptor},
+					{name: "main.sh", body: "#!/bin/sh\n"},
+				}, tc.entries...)
+				if ext == ".zip" {
+					writeZipBundle(t, archive, entries)
+				} else {
+					writeTarBundle(t, archive, entries)
+				}
+
+				dir := filepath.Join(root, "dest", "bundle")
+				if _, err := UnpackBundle(archive, dir); err == nil {
+					t.Fatalf("expected the bundle to be rejected")
+				}
+
+				if files := strayFiles(t, root, archive); len(files) > 0 {
+					t.Errorf("expected nothing to be unpacked, found %v", files)
+				}
+
+				if files := strayFiles(t, outside, ""); len(files) > 0 {
+					t.Errorf("expected nothing to be written outside of the destination, found %v", files)
+				}
+			})
+		}
+	}
+}
+
+func TestUnpackBundleLimits(t *testing.T) {
+	testCases := []struct {
+		name       string
+		maxSize    int64
+		maxEntries int
+		entries    []bundleEntry
+		err        string
+	}{
+		{
+			name:       "too many entries",
+			maxSize:    MaxBundleSize,
+			maxEntries: 3,
+			entries:    []bundleEntry{{name: "a"}, {name: "b"}},
+			err:        "more than 3 entries",
+		},
+		{
+			name:       "too large",
+			maxSize:    64,
+			maxEntries: MaxBundleEntries,
+			entries:    []bundleEntry{{name: "large", body: strings.Repeat("x", 1024)}},
+			err:        "larger than 64 bytes",
+		},
+		{
+			name:       "too large in total",
+			maxSize:    64,
+			maxEntries: MaxBundleEntries,
+			entries:    []bundleEntry{{name: "a", body: strings.Repeat("x", 20)}, {name: "b", body: strings.Repeat("x", 20)}},
+			err:        "larger than 64 bytes",
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, ext := range []string{".tar.gz", ".zip"} {
+			t.Run(tc.name+ext, func(t *testing.T) {
+				maxSize, maxEntries := MaxBundleSize, MaxBundleEntries
+				MaxBundleSize, MaxBundleEntries = tc.maxSize, tc.maxEntries
+				t.Cleanup(func() {
+					MaxBundleSize, MaxBundleEntries = maxSize, maxEntries
+				})
+
+				root := t.TempDir()
+				archive := filepath.Join(root, "bundle"+ext)
+				entries := append([]bundleEntry{
+					{name: BundleDescriptor, body: bundleDescriptor},
+					{name: "main.sh", body: "#!/bin/sh\n"},
+				}, tc.entries...)
+				if ext == ".zip" {
+					writeZipBundle(t, archive, entries)
+				} else {
+					writeTarBundle(t, archive, entries)
+				}
+
+				_, err := UnpackBundle(archive, filepath.Join(root, "bundle"))
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+
+				if files := strayFiles(t, root, archive); len(files) > 0 {
+					t.Errorf("expected the partial bundle to be removed, found %v", files)
+				}
+			})
+		}
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "cache", "bundle")
+	testCases := []struct {
+		name     string
+		expected string
+		invalid  bool
+	}{
+		{name: "main.sh", expected: filepath.Join(dir, "main.sh")},
+		{name: "lib/helper.sh", expected: filepath.Join(dir, "lib", "helper.sh")},
+		{name: "lib/../main.sh", expected: filepath.Join(dir, "main.sh")},
+		{name: "./main.sh", expected: filepath.Join(dir, "main.sh")},
+		{name: "..dotted", expected: filepath.Join(dir, "..dotted")},
+		{name: "../x", invalid: true},
+		{name: "lib/../../x", invalid: true},
+		{name: "..", invalid: true},
+		{name: "/etc/passwd", invalid: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			target, err := safeJoin(dir, tc.name)
+			if tc.invalid {
+				if err == nil {
+					t.Fatalf("expected %s to be rejected, got %s", tc.name, target)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if target != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, target)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package extensions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/fzf"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Catalog is an index of installable extensions
+type Catalog struct {
+	Extensions []CatalogEntry `json:"extensions"`
+}
+
+type CatalogEntry struct {
+	Name     string           `json:"name"`
+	Origin   string           `json:"origin"`
+	Manifest sunbeam.Manifest `json:"manifest"`
+}
+
+// FilterValue is matched against the search query: the name, title, description and commands of the extension
+func (e CatalogEntry) FilterValue() string {
+	keywords := []string{e.Name, e.Manifest.Title, e.Manifest.Description}
+	for _, command := range e.Manifest.Commands {
+		keywords = append(keywords, command.Title)
+	}
+
+	return strings.Join(keywords, " ")
+}
+
+// LoadCatalog reads a catalog index from a local path or an url.
+// Relative origins are resolved from the location of the catalog, not from the working directory.
+func LoadCatalog(location string) (Catalog, error) {
+	var bts []byte
+	if IsRemote(location) {
+		client := http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Get(location)
+		if err != nil {
+			return Catalog{}, fmt.Errorf("failed to download catalog: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return Catalog{}, fmt.Errorf("failed to download catalog: %s", resp.Status)
+		}
+
+		bts, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return Catalog{}, fmt.Errorf("failed to download catalog: %w", err)
+		}
+	} else {
+		var err error
+		bts, err = os.ReadFile(location)
+		if err != nil {
+			return Catalog{}, fmt.Errorf("failed to read catalog: %w", err)
+		}
+	}
+
+	var catalog Catalog
+	if err := json.Unmarshal(bts, &catalog); err != nil {
+		return Catalog{}, fmt.Errorf("failed to decode catalog: %w", err)
+	}
+
+	for i, entry := range catalog.Extensions {
+		if entry.Name == "" || entry.Origin == "" {
+			return Catalog{}, fmt.Errorf("invalid catalog: every extension must have a name and an origin")
+		}
+
+		origin, err := resolveCatalogOrigin(location, entry.Origin)
+		if err != nil {
+			return Catalog{}, fmt.Errorf("invalid catalog: extension %s: %w", entry.Name, err)
+		}
+		catalog.Extensions[i].Origin = origin
+	}
+
+	return catalog, nil
+}
+
+// resolveCatalogOrigin resolves the origins of a remote catalog as references to its url, and the relative paths of a local catalog from its directory
+func resolveCatalogOrigin(location string, origin string) (string, error) {
+	if IsRemote(origin) {
+		return origin, nil
+	}
+
+	if IsRemote(location) {
+		base, err := url.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse catalog url: %w", err)
+		}
+
+		ref, err := url.Parse(filepath.ToSlash(origin))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse origin: %w", err)
+		}
+
+		return base.ResolveReference(ref).String(), nil
+	}
+
+	if strings.HasPrefix(origin, "~/") || filepath.IsAbs(origin) {
+		return origin, nil
+	}
+
+	return filepath.Abs(filepath.Join(filepath.Dir(location), origin))
+}
+
+// Search returns the entries matching the query, best matches first.
+// Entries whose name matches are ranked above the ones only matching their title, description or commands.
+// All the entries are returned, sorted by name, if the query is empty.
+func (c Catalog) Search(query string) []CatalogEntry {
+	entries := make([]CatalogEntry, 0, len(c.Extensions))
+	scores := make(map[string]int)
+	nameScores := make(map[string]int)
+	for _, entry := range c.Extensions {
+		if query == "" {
+			entries = append(entries, entry)
+			continue
+		}
+
+		score := fzf.Score(entry.FilterValue(), query)
+		if score <= 0 {
+			continue
+		}
+
+		scores[entry.Name] = score
+		nameScores[entry.Name] = fzf.Score(entry.Name, query)
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if nameScores[entries[i].Name] != nameScores[entries[j].Name] {
+			return nameScores[entries[i].Name] > nameScores[entries[j].Name]
+		}
+
+		if scores[entries[i].Name] != scores[entries[j].Name] {
+			return scores[entries[i].Name] > scores[entries[j].Name]
+		}
+
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
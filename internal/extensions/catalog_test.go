@@ -0,0 +1,126 @@
+package extensions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const testCatalog = `{
+  "extensions": [
+    {"name": "local", "origin": "./extensions/local.sh", "manifest": {"title": "Local"}},
+    {"name": "absolute", "origin": "/opt/sunbeam/absolute.sh", "manifest": {"title": "Absolute"}},
+    {"name": "home", "origin": "~/extensions/home.sh", "manifest": {"title": "Home"}},
+    {"name": "remote", "origin": "https://example.com/remote.sh", "manifest": {"title": "Remote"}},
+    {"name": "git", "origin": "git+https://github.com/pomdtr/sunbeam-extensions#git.sh", "manifest": {"title": "Git"}}
+  ]
+}`
+
+func catalogOrigins(t *testing.T, location string) map[string]string {
+	t.Helper()
+
+	catalog, err := LoadCatalog(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origins := make(map[string]string)
+	for _, entry := range catalog.Extensions {
+		origins[entry.Name] = entry.Origin
+	}
+
+	return origins
+}
+
+func TestLoadCatalogOrigins(t *testing.T) {
+	t.Run("local", func(t *testing.T) {
+		dir := t.TempDir()
+		location := filepath.Join(dir, "catalog.json")
+		if err := os.WriteFile(location, []byte(testCatalog), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		origins := catalogOrigins(t, location)
+		expected := map[string]string{
+			"local":    filepath.Join(dir, "extensions", "local.sh"),
+			"absolute": "/opt/sunbeam/absolute.sh",
+			"home":     "~/extensions/home.sh",
+			"remote":   "https://example.com/remote.sh",
+			"git":      "git+https://github.com/pomdtr/sunbeam-extensions#git.sh",
+		}
+
+		for name, origin := range expected {
+			if origins[name] != origin {
+				t.Errorf("expected the origin of %s to be %s, got %s", name, origin, origins[name])
+			}
+		}
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(testCatalog))
+		}))
+		t.Cleanup(ts.Close)
+
+		origins := catalogOrigins(t, ts.URL+"/catalogs/catalog.json")
+		expected := map[string]string{
+			"local":    ts.URL + "/catalogs/extensions/local.sh",
+			"absolute": ts.URL + "/opt/sunbeam/absolute.sh",
+			"remote":   "https://example.com/remote.sh",
+		}
+
+		for name, origin := range expected {
+			if origins[name] != origin {
+				t.Errorf("expected the origin of %s to be %s, got %s", name, origin, origins[name])
+			}
+		}
+	})
+}
+
+func TestCatalogSearch(t *testing.T) {
+	catalog := Catalog{
+		Extensions: []CatalogEntry{
+			{Name: "tldr", Origin: "./tldr.sh", Manifest: sunbeam.Manifest{Title: "TLDR Pages", Description: "Browse tldr pages"}},
+			{Name: "github", Origin: "./github.sh", Manifest: sunbeam.Manifest{Title: "GitHub", Description: "Manage your repositories"}},
+			{Name: "gitlab", Origin: "./gitlab.sh", Manifest: sunbeam.Manifest{Title: "GitLab"}},
+			{Name: "devdocs", Origin: "./devdocs.sh", Manifest: sunbeam.Manifest{Title: "DevDocs", Commands: []sunbeam.CommandSpec{{Name: "search", Title: "Search GitHub Docs"}}}},
+		},
+	}
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{query: "", expected: []string{"devdocs", "github", "gitlab", "tldr"}},
+		{query: "github", expected: []string{"github", "devdocs"}},
+		{query: "git", expected: []string{"github", "gitlab", "devdocs"}},
+		{query: "gitl", expected: []string{"gitlab"}},
+		{query: "docs", expected: []string{"devdocs"}},
+		{query: "repositories", expected: []string{"github"}},
+		{query: "zzz", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			entries := catalog.Search(tc.query)
+			names := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+			}
+
+			if len(names) != len(tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, names)
+			}
+
+			for i := range names {
+				if names[i] != tc.expected[i] {
+					t.Fatalf("expected %v, got %v", tc.expected, names)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package extensions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/schemas"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Debug enables the recording of every extension invocation, to be shown in the inspector
+var Debug bool
+
+// MaxInvocations is the number of invocations kept in debug mode, the oldest are dropped first
+const MaxInvocations = 100
+
+// Invocation is a recorded run of an extension.
+// Commands run in tty mode are not recorded, since their output is not captured.
+type Invocation struct {
+	ID         int    `json:"id"`
+	Alias      string `json:"alias,omitempty"`
+	Entrypoint string `json:"entrypoint"`
+	Command    string `json:"command,omitempty"`
+	// Payload is empty when the manifest was extracted
+	Payload   string        `json:"payload,omitempty"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration"`
+	ExitCode  int           `json:"exitCode"`
+	Stdout    string        `json:"stdout"`
+	Stderr    string        `json:"stderr"`
+	// Schema is the schema the output was validated against, if any
+	Schema          string `json:"schema,omitempty"`
+	ValidationError string `json:"validationError,omitempty"`
+}
+
+var recorder struct {
+	mu          sync.Mutex
+	nextID      int
+	invocations []Invocation
+}
+
+// Invocations returns the recorded invocations, oldest first
+func Invocations() []Invocation {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	invocations := make([]Invocation, len(recorder.invocations))
+	copy(invocations, recorder.invocations)
+	return invocations
+}
+
+func recordInvocation(invocation Invocation) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.nextID++
+	invocation.ID = recorder.nextID
+	recorder.invocations = append(recorder.invocations, invocation)
+	if len(recorder.invocations) > MaxInvocations {
+		recorder.invocations = recorder.invocations[len(recorder.invocations)-MaxInvocations:]
+	}
+}
+
+// outputSchema returns the name and the validator of the output expected from a command
+func outputSchema(mode sunbeam.CommandMode) (string, func([]byte) error) {
+	switch mode {
+	case sunbeam.CommandModeSearch, sunbeam.CommandModeFilter:
+		return "list", schemas.ValidateList
+	case sunbeam.CommandModeDetail:
+		return "detail", schemas.ValidateDetail
+	default:
+		return "", nil
+	}
+}
+
+// captureOutput runs the command as cmd.Output does, and writes the run to the log of the extension.
+// In debug mode, the output is validated against the schema if there is one, and the run is recorded.
+func captureOutput(cmd *exec.Cmd, alias string, command string, schema string, validate func([]byte) error) ([]byte, error) {
+	if ReplayPath != "" && alias != "" {
+		return replayOutput(alias, cmd)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	startedAt := time.Now()
+	stdout, err := cmd.Output()
+	duration := time.Since(startedAt)
+
+	// callers read the stderr of the exit error, which is not set when stderr is redirected
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitErr.Stderr = stderr.Bytes()
+	}
+
+	if alias != "" {
+		// logs are best effort, a run never fails because of them
+		_ = WriteLog(LogEntry{
+			Time:     startedAt,
+			Alias:    alias,
+			Command:  command,
+			ExitCode: exitCode(cmd),
+			Duration: duration,
+			Stderr:   stderr.String(),
+		})
+	}
+
+	if RecordPath != "" && alias != "" {
+		if err := recordInteraction(alias, cmd, stdout, stderr.Bytes()); err != nil {
+			return nil, fmt.Errorf("failed to record interaction: %w", err)
+		}
+	}
+
+	if !Debug {
+		return stdout, err
+	}
+
+	invocation := Invocation{
+		Alias:      alias,
+		Entrypoint: cmd.Path,
+		Command:    command,
+		StartedAt:  startedAt,
+		Duration:   duration,
+		ExitCode:   exitCode(cmd),
+		Stdout:     string(stdout),
+		Stderr:     stderr.String(),
+	}
+
+	if len(cmd.Args) > 1 {
+		invocation.Payload = cmd.Args[1]
+	}
+
+	if err == nil && validate != nil {
+		invocation.Schema = schema
+		if err := validate(stdout); err != nil {
+			invocation.ValidationError = err.Error()
+		}
+	}
+
+	recordInvocation(invocation)
+	return stdout, err
+}
+
+// PrettyJSON indents json documents, other strings are returned as is
+func PrettyJSON(s string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(s), "", "  "); err != nil {
+		return s
+	}
+
+	return out.String()
+}
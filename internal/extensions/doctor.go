@@ -0,0 +1,165 @@
+package extensions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+type DoctorStatus string
+
+const (
+	DoctorStatusOK        DoctorStatus = "ok"
+	DoctorStatusFailed    DoctorStatus = "failed"
+	DoctorStatusSkipped   DoctorStatus = "skipped"
+	DoctorStatusNotCached DoctorStatus = "not cached"
+)
+
+// DoctorCheck is the result of a single check, the message explains failures
+type DoctorCheck struct {
+	Name    string       `json:"name"`
+	Status  DoctorStatus `json:"status"`
+	Message string       `json:"message,omitempty"`
+}
+
+// DoctorReport lists the checks of an extension, in the order they ran
+type DoctorReport struct {
+	Alias   string        `json:"alias"`
+	Origin  string        `json:"origin"`
+	Healthy bool          `json:"healthy"`
+	Checks  []DoctorCheck `json:"checks"`
+}
+
+// Check returns the check with the given name
+func (r DoctorReport) Check(name string) (DoctorCheck, bool) {
+	for _, check := range r.Checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+
+	return DoctorCheck{}, false
+}
+
+// Doctor checks that an extension is ready to run: its entrypoint is cached and executable, its manifest is valid,
+// its required preferences are set and its requirements are installed. The checks depending on a failed one are skipped.
+// Nothing is downloaded and the lockfile is not modified, extensions which were never fetched are reported as not cached without being unhealthy.
+func Doctor(cfg config.Config, alias string, lockfile *Lockfile) (DoctorReport, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return DoctorReport{}, fmt.Errorf("extension %s not found", alias)
+	}
+
+	origin := resolveOrigin(cfg, extensionConfig.Origin)
+	report := DoctorReport{
+		Alias:   alias,
+		Origin:  extensionConfig.Origin,
+		Healthy: true,
+	}
+
+	var failed bool
+	check := func(name string, fn func() error) {
+		if failed {
+			report.Checks = append(report.Checks, DoctorCheck{Name: name, Status: DoctorStatusSkipped})
+			return
+		}
+
+		err := fn()
+		if errors.Is(err, ErrNotCached) {
+			failed = true
+			report.Checks = append(report.Checks, DoctorCheck{Name: name, Status: DoctorStatusNotCached, Message: fmt.Sprintf("run sunbeam %s to download it", alias)})
+			return
+		}
+
+		if err != nil {
+			failed = true
+			report.Healthy = false
+			report.Checks = append(report.Checks, DoctorCheck{Name: name, Status: DoctorStatusFailed, Message: err.Error()})
+			return
+		}
+
+		report.Checks = append(report.Checks, DoctorCheck{Name: name, Status: DoctorStatusOK})
+	}
+
+	extension := Extension{
+		Alias:    alias,
+		Timeouts: NewTimeouts(cfg.ExtensionTimeouts(alias)),
+		Env:      NewEnvPolicy(extensionConfig),
+	}
+
+	check("origin", func() error {
+		extensionDir, err := ExtensionDir(origin)
+		if err != nil {
+			return err
+		}
+
+		entrypoint, err := CachedEntrypoint(origin, extensionDir, lockfile)
+		if err != nil {
+			return err
+		}
+
+		extension.Entrypoint = entrypoint
+		return nil
+	})
+
+	check("executable", func() error {
+		info, err := os.Stat(extension.Entrypoint)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", extension.Entrypoint)
+		}
+
+		if info.Mode().Perm()&0111 == 0 {
+			return fmt.Errorf("%s is not executable, run chmod +x %s", extension.Entrypoint, extension.Entrypoint)
+		}
+
+		return nil
+	})
+
+	check("manifest", func() error {
+		manifest, err := extension.ExtractManifest()
+		if err != nil {
+			return err
+		}
+
+		extension.Manifest = manifest
+		return nil
+	})
+
+	// preferences and requirements are independent, a failure of one does not skip the other
+	manifestFailed := failed
+	check("preferences", func() error {
+		preferences, err := PreferencesFromEnv(alias, extension.Manifest.Preferences)
+		if err != nil {
+			return err
+		}
+
+		var missing []string
+		for _, preference := range extension.Manifest.Preferences {
+			if preference.Optional || extensionConfig.Preferences[preference.Name] != nil || preferences[preference.Name] != nil {
+				continue
+			}
+
+			missing = append(missing, fmt.Sprintf("%s (set it in the config or with %s)", preference.Name, PreferenceEnv(alias, preference.Name)))
+		}
+
+		if len(missing) > 0 {
+			return fmt.Errorf("missing required preferences: %s", strings.Join(missing, ", "))
+		}
+
+		return nil
+	})
+
+	failed = manifestFailed
+	check("requirements", func() error {
+		return extension.CheckRequirements()
+	})
+
+	return report, nil
+}
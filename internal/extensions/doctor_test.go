@@ -0,0 +1,197 @@
+package extensions
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+// doctorScript declares a required token preference, and a requirement on the given executable
+func doctorScript(requirement string) string {
+	return fmt.Sprintf(`#!/bin/sh
+echo '{"title": "Doctor", "preferences": [{"name": "api-token", "title": "Token", "type": "string"}], "requirements": [{"name": "%s"}], "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}]}'
+`, requirement)
+}
+
+func doctor(t *testing.T, cfg config.Config, alias string) DoctorReport {
+	t.Helper()
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Doctor(cfg, alias, lockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return report
+}
+
+func TestDoctor(t *testing.T) {
+	testCases := []struct {
+		name        string
+		script      string
+		perm        os.FileMode
+		preferences map[string]any
+		env         map[string]string
+		healthy     bool
+		statuses    map[string]DoctorStatus
+		message     string
+	}{
+		{
+			name:        "healthy",
+			script:      doctorScript("sh"),
+			perm:        0755,
+			preferences: map[string]any{"api-token": "secret"},
+			healthy:     true,
+			statuses:    map[string]DoctorStatus{"origin": DoctorStatusOK, "executable": DoctorStatusOK, "manifest": DoctorStatusOK, "preferences": DoctorStatusOK, "requirements": DoctorStatusOK},
+		},
+		{
+			name:     "preference from env",
+			script:   doctorScript("sh"),
+			perm:     0755,
+			env:      map[string]string{"DOCTOR_API_TOKEN": "secret"},
+			healthy:  true,
+			statuses: map[string]DoctorStatus{"preferences": DoctorStatusOK, "requirements": DoctorStatusOK},
+		},
+		{
+			name:     "not executable",
+			script:   doctorScript("sh"),
+			perm:     0644,
+			statuses: map[string]DoctorStatus{"origin": DoctorStatusOK, "executable": DoctorStatusFailed, "manifest": DoctorStatusSkipped, "preferences": DoctorStatusSkipped, "requirements": DoctorStatusSkipped},
+			message:  "chmod +x",
+		},
+		{
+			name:     "invalid manifest",
+			script:   "#!/bin/sh\necho 'not a manifest'\n",
+			perm:     0755,
+			statuses: map[string]DoctorStatus{"executable": DoctorStatusOK, "manifest": DoctorStatusFailed, "preferences": DoctorStatusSkipped, "requirements": DoctorStatusSkipped},
+		},
+		{
+			name:     "missing preference",
+			script:   doctorScript("sh"),
+			perm:     0755,
+			statuses: map[string]DoctorStatus{"manifest": DoctorStatusOK, "preferences": DoctorStatusFailed, "requirements": DoctorStatusOK},
+			message:  "DOCTOR_API_TOKEN",
+		},
+		{
+			name:        "missing requirement",
+			script:      doctorScript("sunbeam-missing-requirement"),
+			perm:        0755,
+			preferences: map[string]any{"api-token": "secret"},
+			statuses:    map[string]DoctorStatus{"preferences": DoctorStatusOK, "requirements": DoctorStatusFailed},
+			message:     "sunbeam-missing-requirement",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := isolateCache(t)
+			t.Setenv("DOCTOR_API_TOKEN", "")
+			os.Unsetenv("DOCTOR_API_TOKEN")
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			if err := os.WriteFile(filepath.Join(dir, "doctor.sh"), []byte(tc.script), tc.perm); err != nil {
+				t.Fatal(err)
+			}
+
+			// relative origins are resolved from the directory of the config
+			cfg := writeConfig(t, dir, map[string]config.ExtensionConfig{
+				"doctor": {Origin: "./doctor.sh", Preferences: tc.preferences},
+			})
+
+			report := doctor(t, cfg, "doctor")
+			if report.Healthy != tc.healthy {
+				t.Errorf("expected healthy to be %t, got %t: %v", tc.healthy, report.Healthy, report.Checks)
+			}
+
+			if len(report.Checks) != 5 {
+				t.Fatalf("expected every check to be reported, got %v", report.Checks)
+			}
+
+			for name, status := range tc.statuses {
+				check, ok := report.Check(name)
+				if !ok {
+					t.Fatalf("expected the %s check to be reported", name)
+				}
+
+				if check.Status != status {
+					t.Errorf("expected the %s check to be %s, got %s (%s)", name, status, check.Status, check.Message)
+				}
+
+				if status == DoctorStatusFailed && !strings.Contains(check.Message, tc.message) {
+					t.Errorf("expected the %s check message to contain %q, got %q", name, tc.message, check.Message)
+				}
+			}
+		})
+	}
+}
+
+func TestDoctorNotCached(t *testing.T) {
+	server := &extensionServer{body: scriptV1}
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	dir := isolateCache(t)
+	cfg := writeConfig(t, dir, map[string]config.ExtensionConfig{
+		"remote": {Origin: ts.URL + "/remote.sh"},
+	})
+
+	report := doctor(t, cfg, "remote")
+	if !report.Healthy {
+		t.Errorf("expected an extension which was never fetched not to be unhealthy: %v", report.Checks)
+	}
+
+	if check, _ := report.Check("origin"); check.Status != DoctorStatusNotCached {
+		t.Errorf("expected the origin to be reported as not cached, got %s", check.Status)
+	}
+
+	if check, _ := report.Check("manifest"); check.Status != DoctorStatusSkipped {
+		t.Errorf("expected the manifest check to be skipped, got %s", check.Status)
+	}
+
+	if len(server.requests) > 0 {
+		t.Errorf("expected the doctor not to download the extension, got %d requests", len(server.requests))
+	}
+
+	if _, err := os.Stat(LockPath(cfg)); err == nil {
+		t.Errorf("expected the lockfile not to be written")
+	}
+}
+
+func TestDoctorCached(t *testing.T) {
+	server := &extensionServer{body: scriptV1}
+	cfg := installRemote(t, server)
+	requests := len(server.requests)
+
+	report := doctor(t, cfg, "remote")
+	if !report.Healthy {
+		t.Fatalf("expected the installed extension to be healthy: %v", report.Checks)
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != DoctorStatusOK {
+			t.Errorf("expected the %s check to pass, got %s (%s)", check.Name, check.Status, check.Message)
+		}
+	}
+
+	if len(server.requests) != requests {
+		t.Errorf("expected the doctor not to fetch the extension again")
+	}
+}
+
+func TestDoctorUnknownAlias(t *testing.T) {
+	cfg := writeConfig(t, isolateCache(t), map[string]config.ExtensionConfig{})
+	if _, err := Doctor(cfg, "missing", &Lockfile{}); err == nil {
+		t.Errorf("expected an unknown alias to be rejected")
+	}
+}
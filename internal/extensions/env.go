@@ -0,0 +1,88 @@
+package extensions
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+// DefaultRemoteAllow is the list of variables passed to remote extensions when no allowlist is configured
+var DefaultRemoteAllow = []string{
+	"PATH",
+	"HOME",
+	"USER",
+	"SHELL",
+	"TERM",
+	"COLORTERM",
+	"NO_COLOR",
+	"LANG",
+	"LC_*",
+	"TZ",
+	"TMPDIR",
+	"XDG_*",
+	"EDITOR",
+	"VISUAL",
+	"PAGER",
+}
+
+type EnvPolicy struct {
+	Allow []string
+	Deny  []string
+	Extra map[string]string
+}
+
+func NewEnvPolicy(extensionConfig config.ExtensionConfig) EnvPolicy {
+	var policy EnvPolicy
+	if extensionConfig.Env != nil {
+		policy.Allow = extensionConfig.Env.Allow
+		policy.Deny = extensionConfig.Env.Deny
+		policy.Extra = extensionConfig.Env.Extra
+	}
+
+	if len(policy.Allow) == 0 && IsRemote(extensionConfig.Origin) {
+		policy.Allow = DefaultRemoteAllow
+	}
+
+	return policy
+}
+
+// Environ filters the environment of the current process according to the policy.
+// An empty allowlist allows every variable, patterns use the syntax of path.Match.
+// References in the extra variables are expanded against the filtered environment, so that they can not leak a denied variable.
+func (p EnvPolicy) Environ() []string {
+	env := make([]string, 0)
+	values := make(map[string]string)
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if len(p.Allow) > 0 && !matchEnv(p.Allow, name) {
+			continue
+		}
+
+		if matchEnv(p.Deny, name) {
+			continue
+		}
+
+		env = append(env, entry)
+		values[name] = value
+	}
+
+	for name, value := range p.Extra {
+		env = append(env, name+"="+os.Expand(value, func(name string) string {
+			return values[name]
+		}))
+	}
+
+	return append(env, "SUNBEAM=1")
+}
+
+func matchEnv(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
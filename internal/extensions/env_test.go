@@ -0,0 +1,116 @@
+package extensions
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+// environ returns the variables of the policy, keyed by name
+func environ(policy EnvPolicy) map[string]string {
+	env := make(map[string]string)
+	for _, entry := range policy.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		env[name] = value
+	}
+
+	return env
+}
+
+func TestNewEnvPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config config.ExtensionConfig
+		allow  []string
+	}{
+		{name: "local", config: config.ExtensionConfig{Origin: "/path/to/ext.sh"}},
+		{name: "remote", config: config.ExtensionConfig{Origin: "https://example.com/ext.sh"}, allow: DefaultRemoteAllow},
+		{name: "git", config: config.ExtensionConfig{Origin: "git+https://github.com/pomdtr/ext.git"}, allow: DefaultRemoteAllow},
+		{
+			name:   "remote with an allowlist",
+			config: config.ExtensionConfig{Origin: "https://example.com/ext.sh", Env: &config.EnvConfig{Allow: []string{"PATH"}}},
+			allow:  []string{"PATH"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := NewEnvPolicy(tc.config)
+			if !slices.Equal(policy.Allow, tc.allow) {
+				t.Errorf("expected the allowlist %v, got %v", tc.allow, policy.Allow)
+			}
+		})
+	}
+}
+
+func TestEnvPolicyEnviron(t *testing.T) {
+	t.Setenv("HOME", "/home/sunbeam")
+	t.Setenv("LC_ALL", "C")
+	t.Setenv("GITHUB_TOKEN", "s3cr3t")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "s3cr3t")
+	t.Setenv("AWS_REGION", "eu-west-3")
+
+	for _, tc := range []struct {
+		name     string
+		policy   EnvPolicy
+		included map[string]string
+		excluded []string
+	}{
+		{
+			name:     "local default",
+			policy:   NewEnvPolicy(config.ExtensionConfig{Origin: "/path/to/ext.sh"}),
+			included: map[string]string{"HOME": "/home/sunbeam", "GITHUB_TOKEN": "s3cr3t", "SUNBEAM": "1"},
+		},
+		{
+			name:     "remote default",
+			policy:   NewEnvPolicy(config.ExtensionConfig{Origin: "https://example.com/ext.sh"}),
+			included: map[string]string{"HOME": "/home/sunbeam", "LC_ALL": "C", "SUNBEAM": "1"},
+			excluded: []string{"GITHUB_TOKEN", "AWS_SECRET_ACCESS_KEY"},
+		},
+		{
+			name:     "glob",
+			policy:   EnvPolicy{Allow: []string{"AWS_*"}},
+			included: map[string]string{"AWS_REGION": "eu-west-3", "AWS_SECRET_ACCESS_KEY": "s3cr3t"},
+			excluded: []string{"HOME", "GITHUB_TOKEN"},
+		},
+		{
+			name:     "deny wins over allow",
+			policy:   EnvPolicy{Allow: []string{"AWS_*"}, Deny: []string{"AWS_SECRET_*"}},
+			included: map[string]string{"AWS_REGION": "eu-west-3"},
+			excluded: []string{"AWS_SECRET_ACCESS_KEY"},
+		},
+		{
+			name:     "deny without allowlist",
+			policy:   EnvPolicy{Deny: []string{"GITHUB_TOKEN"}},
+			included: map[string]string{"HOME": "/home/sunbeam"},
+			excluded: []string{"GITHUB_TOKEN"},
+		},
+		{
+			name:     "extra",
+			policy:   EnvPolicy{Allow: []string{"HOME"}, Extra: map[string]string{"CONFIG_DIR": "$HOME/.config", "REGION": "${AWS_REGION}"}},
+			included: map[string]string{"CONFIG_DIR": "/home/sunbeam/.config", "REGION": ""},
+			excluded: []string{"AWS_REGION"},
+		},
+		{
+			name:     "extra can not leak a denied variable",
+			policy:   NewEnvPolicy(config.ExtensionConfig{Origin: "https://example.com/ext.sh", Env: &config.EnvConfig{Extra: map[string]string{"X": "$GITHUB_TOKEN"}}}),
+			included: map[string]string{"X": ""},
+			excluded: []string{"GITHUB_TOKEN"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := environ(tc.policy)
+			for name, value := range tc.included {
+				if got, ok := env[name]; !ok || got != value {
+					t.Errorf("expected %s=%s, got %q", name, value, got)
+				}
+			}
+
+			for _, name := range tc.excluded {
+				if _, ok := env[name]; ok {
+					t.Errorf("expected %s to be filtered out", name)
+				}
+			}
+		})
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/acarl005/stripansi"
 	"github.com/pomdtr/sunbeam/internal/config"
@@ -32,8 +34,57 @@ func (e ExtensionMap) List() []Extension {
 }
 
 type Extension struct {
+	Alias      string `json:"alias"`
 	Manifest   sunbeam.Manifest
-	Entrypoint string `json:"entrypoint"`
+	Entrypoint string    `json:"entrypoint"`
+	Timeouts   Timeouts  `json:"-"`
+	Env        EnvPolicy `json:"-"`
+}
+
+const (
+	DefaultManifestTimeout = 10 * time.Second
+	DefaultOutputTimeout   = 60 * time.Second
+)
+
+type Timeouts struct {
+	Manifest time.Duration
+	// Run is zero unless configured, commands streaming their output are not bounded then
+	Run time.Duration
+}
+
+// Output returns the timeout of commands whose output is captured, it defaults to DefaultOutputTimeout
+func (t Timeouts) Output() time.Duration {
+	if t.Run > 0 {
+		return t.Run
+	}
+
+	return DefaultOutputTimeout
+}
+
+func NewTimeouts(timeouts config.Timeouts) Timeouts {
+	res := Timeouts{
+		Manifest: DefaultManifestTimeout,
+	}
+
+	if timeouts.Manifest > 0 {
+		res.Manifest = time.Duration(timeouts.Manifest) * time.Second
+	}
+
+	if timeouts.Run > 0 {
+		res.Run = time.Duration(timeouts.Run) * time.Second
+	}
+
+	return res
+}
+
+type TimeoutError struct {
+	Alias   string
+	Timeout time.Duration
+	Op      string
+}
+
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("extension %s timed out after %s while %s", e.Alias, e.Timeout, e.Op)
 }
 
 type Preferences map[string]any
@@ -79,14 +130,27 @@ func (e Extension) Run(input sunbeam.Payload) error {
 }
 
 func (ext Extension) Output(input sunbeam.Payload) ([]byte, error) {
-	cmd, err := ext.Cmd(input)
+	return ext.OutputContext(context.Background(), input)
+}
+
+// OutputContext runs the command and returns its output, the output timeout of the extension is enforced
+func (ext Extension) OutputContext(ctx context.Context, input sunbeam.Payload) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, ext.Timeouts.Output())
+	defer cancel()
+
+	cmd, err := ext.CmdContext(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
+	command, _ := ext.Command(input.Command)
+	schema, validate := outputSchema(command.Mode)
+
 	var exitErr *exec.ExitError
-	if output, err := cmd.Output(); err == nil {
+	if output, err := captureOutput(cmd, ext.Alias, input.Command, schema, validate); err == nil {
 		return output, nil
+	} else if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, TimeoutError{Alias: ext.Alias, Timeout: ext.Timeouts.Output(), Op: fmt.Sprintf("running command %s", input.Command)}
 	} else if errors.As(err, &exitErr) {
 		return nil, fmt.Errorf("command failed: %s", stripansi.Strip(string(exitErr.Stderr)))
 	} else {
@@ -108,7 +172,8 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 			continue
 		}
 
-		if !spec.Optional {
+		// preferences are not recorded in fixtures, replayed runs do not need them
+		if !spec.Optional && ReplayPath == "" {
 			return nil, fmt.Errorf("missing required preference %s", spec.Name)
 		}
 
@@ -149,14 +214,16 @@ func (e Extension) CmdContext(ctx context.Context, input sunbeam.Payload) (*exec
 
 	cmd := exec.CommandContext(ctx, e.Entrypoint, string(inputBytes))
 	cmd.Dir = filepath.Dir(e.Entrypoint)
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, "SUNBEAM=1")
+	cmd.Env = e.Env.Environ()
+	// do not wait forever for the children of a killed process to close stdout
+	cmd.WaitDelay = time.Second
 	return cmd, nil
 }
 
+// Hash returns the name of the cache directory of an origin, local origins are resolved first so that they share it however they are written
 func Hash(origin string) (string, error) {
 	if !IsRemote(origin) {
-		abs, err := filepath.Abs(origin)
+		abs, err := ResolveLocalPath(origin)
 		if err != nil {
 			return "", err
 		}
@@ -169,64 +236,97 @@ func Hash(origin string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
+// ExtensionDir returns the cache directory of an extension
+func ExtensionDir(origin string) (string, error) {
+	hash, err := Hash(origin)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(utils.CacheDir(), "extensions", hash), nil
+}
+
+// IsRemote reports whether the extension is downloaded by sunbeam, either from an url or from a git repository
 func IsRemote(origin string) bool {
-	return strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://")
+	return strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://") || IsGit(origin)
 }
 
-func DownloadEntrypoint(origin string, target string) error {
+// DownloadEntrypoint downloads a remote file to target, and returns the cache validators sent by the server
+func DownloadEntrypoint(origin string, target string) (CacheValidators, error) {
 	resp, err := http.Get(origin)
 	if err != nil {
-		return fmt.Errorf("failed to download extension: %w", err)
+		return CacheValidators{}, fmt.Errorf("failed to download extension: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("failed to download extension: %s", resp.Status)
+		return CacheValidators{}, fmt.Errorf("failed to download extension: %s", resp.Status)
 	}
 
 	f, err := os.Create(target)
 	if err != nil {
-		return fmt.Errorf("failed to create entrypoint: %w", err)
+		return CacheValidators{}, fmt.Errorf("failed to create entrypoint: %w", err)
 	}
 
 	if _, err := f.ReadFrom(resp.Body); err != nil {
-		return fmt.Errorf("failed to write entrypoint: %w", err)
+		return CacheValidators{}, fmt.Errorf("failed to write entrypoint: %w", err)
 	}
 
 	if err := f.Close(); err != nil {
-		return fmt.Errorf("failed to close entrypoint: %w", err)
+		return CacheValidators{}, fmt.Errorf("failed to close entrypoint: %w", err)
 	}
 
-	return nil
+	return responseValidators(resp), nil
 }
 
-func LoadEntrypoint(origin string, extensionDir string) (string, error) {
+// ResolveEntrypoint returns the path of the entrypoint, without downloading remote extensions.
+// Bundles must have been unpacked already.
+func ResolveEntrypoint(origin string, extensionDir string) (string, error) {
+	if IsGit(origin) {
+		gitOrigin, err := ParseGitOrigin(origin)
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(extensionDir, "repo", gitOrigin.Path), nil
+	}
+
+	if IsBundle(origin) {
+		return resolveBundleEntrypoint(filepath.Join(extensionDir, "bundle"))
+	}
+
 	if IsRemote(origin) {
 		originUrl, err := url.Parse(origin)
 		if err != nil {
 			return "", fmt.Errorf("failed to parse origin: %w", err)
 		}
 
-		entrypoint := filepath.Join(extensionDir, filepath.Base(originUrl.Path))
-		if _, err := os.Stat(entrypoint); err == nil {
-			return entrypoint, nil
-		}
+		return filepath.Join(extensionDir, filepath.Base(originUrl.Path)), nil
+	}
 
-		if err := os.MkdirAll(extensionDir, 0755); err != nil {
-			return "", fmt.Errorf("failed to create directory: %w", err)
-		}
+	return ResolveLocalPath(origin)
+}
 
-		if err := DownloadEntrypoint(origin, entrypoint); err != nil {
-			return "", err
-		}
+// indexPath returns the manifest index of a config, configs built in memory keep their own in their state directory
+func indexPath(cfg config.Config) string {
+	if cfg.StateDir() != "" {
+		return filepath.Join(cfg.StateDir(), "index.json")
+	}
 
-		if err := os.Chmod(entrypoint, 0755); err != nil {
-			return "", fmt.Errorf("failed to chmod entrypoint: %w", err)
-		}
+	return IndexPath
+}
 
-		return entrypoint, nil
+// resolveOrigin resolves local origins from the directory of the config declaring them, which may not be the global one
+func resolveOrigin(cfg config.Config, origin string) string {
+	if IsRemote(origin) {
+		return origin
 	}
 
+	return cfg.Resolve(origin)
+}
+
+// ResolveLocalPath returns the absolute path of a local origin, relative paths are resolved from the config directory
+func ResolveLocalPath(origin string) (string, error) {
 	entrypoint := origin
 	if strings.HasPrefix(entrypoint, "~") {
 		entrypoint = strings.Replace(entrypoint, "~", os.Getenv("HOME"), 1)
@@ -237,115 +337,424 @@ func LoadEntrypoint(origin string, extensionDir string) (string, error) {
 	return filepath.Abs(entrypoint)
 }
 
-func LoadExtension(origin string) (Extension, error) {
-	hash, err := Hash(origin)
+// LoadEntrypoint downloads remote extensions, clones git ones or unpacks bundles if needed, and verifies them against the lockfile
+func LoadEntrypoint(origin string, extensionDir string, lockfile *Lockfile) (string, error) {
+	entrypoint, _, err := loadEntrypoint(origin, extensionDir, lockfile)
+	return entrypoint, err
+}
+
+// ErrNotCached is returned by CachedEntrypoint when a remote extension was never downloaded
+var ErrNotCached = errors.New("not cached")
+
+// CachedEntrypoint resolves the entrypoint of an origin and verifies it against the lockfile, like LoadEntrypoint.
+// It never downloads, clones or unpacks anything, ErrNotCached is returned instead.
+func CachedEntrypoint(origin string, extensionDir string, lockfile *Lockfile) (string, error) {
+	if IsBundle(origin) {
+		archive, err := bundleArchive(origin, extensionDir)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := os.Stat(archive); err != nil {
+			if IsRemote(origin) {
+				return "", ErrNotCached
+			}
+			return "", fmt.Errorf("failed to find bundle: %w", err)
+		}
+
+		if IsRemote(origin) {
+			if err := lockfile.Verify(origin, archive); err != nil {
+				return "", err
+			}
+		}
+
+		bundleDir := filepath.Join(extensionDir, "bundle")
+		if _, err := os.Stat(bundleDir); err != nil {
+			return "", ErrNotCached
+		}
+
+		return resolveBundleEntrypoint(bundleDir)
+	}
+
+	entrypoint, err := ResolveEntrypoint(origin, extensionDir)
+	if err != nil {
+		return "", err
+	}
+
+	if !IsRemote(origin) {
+		if _, err := os.Stat(entrypoint); err != nil {
+			return "", fmt.Errorf("entrypoint %s not found", entrypoint)
+		}
+		return entrypoint, nil
+	}
+
+	if IsGit(origin) {
+		repoDir := filepath.Join(extensionDir, "repo")
+		if _, err := os.Stat(repoDir); err != nil {
+			return "", ErrNotCached
+		}
+
+		if err := lockfile.VerifyRepository(origin, repoDir, entrypoint); err != nil {
+			return "", err
+		}
+
+		return entrypoint, nil
+	}
+
+	if _, err := os.Stat(entrypoint); err != nil {
+		return "", ErrNotCached
+	}
+
+	if err := lockfile.Verify(origin, entrypoint); err != nil {
+		return "", err
+	}
+
+	return entrypoint, nil
+}
+
+// loadEntrypoint is LoadEntrypoint, the cache validators are only set if the entrypoint was just downloaded
+func loadEntrypoint(origin string, extensionDir string, lockfile *Lockfile) (string, CacheValidators, error) {
+	if IsBundle(origin) {
+		return loadBundleEntrypoint(origin, extensionDir, lockfile)
+	}
+
+	entrypoint, err := ResolveEntrypoint(origin, extensionDir)
+	if err != nil {
+		return "", CacheValidators{}, err
+	}
+
+	if !IsRemote(origin) {
+		return entrypoint, CacheValidators{}, nil
+	}
+
+	if IsGit(origin) {
+		entrypoint, err := loadGitEntrypoint(origin, extensionDir, entrypoint, lockfile)
+		return entrypoint, CacheValidators{}, err
+	}
+
+	if _, err := os.Stat(entrypoint); err == nil {
+		if err := lockfile.Verify(origin, entrypoint); err != nil {
+			return "", CacheValidators{}, err
+		}
+
+		return entrypoint, CacheValidators{}, nil
+	}
+
+	if err := lockfile.CheckPinned(origin); err != nil {
+		return "", CacheValidators{}, err
+	}
+
+	if err := os.MkdirAll(extensionDir, 0755); err != nil {
+		return "", CacheValidators{}, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	validators, err := DownloadEntrypoint(origin, entrypoint)
+	if err != nil {
+		return "", CacheValidators{}, err
+	}
+
+	if err := lockfile.Verify(origin, entrypoint); err != nil {
+		os.Remove(entrypoint)
+		return "", CacheValidators{}, err
+	}
+
+	if err := os.Chmod(entrypoint, 0755); err != nil {
+		return "", CacheValidators{}, fmt.Errorf("failed to chmod entrypoint: %w", err)
+	}
+
+	return entrypoint, validators, nil
+}
+
+// CachedManifest looks up the manifest of an extension in the index.
+// It never downloads or executes the extension, so it is safe to call for every configured extension.
+func CachedManifest(index *Index, origin string) (sunbeam.Manifest, bool) {
+	extensionDir, err := ExtensionDir(origin)
+	if err != nil {
+		return sunbeam.Manifest{}, false
+	}
+
+	entrypoint, err := ResolveEntrypoint(origin, extensionDir)
+	if err != nil {
+		return sunbeam.Manifest{}, false
+	}
+
+	modTime, err := manifestModTime(entrypoint)
+	if err != nil {
+		return sunbeam.Manifest{}, false
+	}
+
+	return index.Get(indexKey(origin, entrypoint), modTime)
+}
+
+// FetchExtension downloads the entrypoint of an extension if needed, and reads its static manifest without executing anything.
+// The lockfile is not updated, so that nothing is pinned until the extension is loaded.
+func FetchExtension(cfg config.Config, alias string) (string, sunbeam.Manifest, bool, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return "", sunbeam.Manifest{}, false, fmt.Errorf("extension %s not found", alias)
+	}
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		return "", sunbeam.Manifest{}, false, err
+	}
+
+	origin := resolveOrigin(cfg, extensionConfig.Origin)
+	extensionDir, err := ExtensionDir(origin)
+	if err != nil {
+		return "", sunbeam.Manifest{}, false, err
+	}
+
+	entrypoint, err := LoadEntrypoint(origin, extensionDir, lockfile)
+	if err != nil {
+		return "", sunbeam.Manifest{}, false, err
+	}
+
+	manifest, ok, err := ReadStaticManifest(entrypoint)
+	if err != nil {
+		return "", sunbeam.Manifest{}, false, err
+	}
+
+	return entrypoint, manifest, ok, nil
+}
+
+// LoadExtension loads a single extension, using the manifest index when possible
+func LoadExtension(cfg config.Config, alias string) (Extension, error) {
+	index, err := LoadIndex(indexPath(cfg))
 	if err != nil {
 		return Extension{}, err
 	}
-	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
-	entrypoint, err := LoadEntrypoint(origin, extensionDir)
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
 	if err != nil {
 		return Extension{}, err
 	}
 
-	entrypointInfo, err := os.Stat(entrypoint)
+	extension, err := loadExtension(index, lockfile, cfg, alias)
 	if err != nil {
 		return Extension{}, err
 	}
 
-	manifestPath := filepath.Join(extensionDir, "manifest.json")
-	manifestInfo, err := os.Stat(manifestPath)
-	if err != nil || entrypointInfo.ModTime().After(manifestInfo.ModTime()) {
-		manifest, err := cacheManifest(entrypoint, manifestPath)
+	if err := index.Save(); err != nil {
+		return Extension{}, err
+	}
+
+	if err := lockfile.Save(); err != nil {
+		return Extension{}, err
+	}
+
+	return extension, nil
+}
+
+// LoadExtensions concurrently loads all the extensions of the config.
+// Extensions that failed to load are reported in the error map, keyed by alias.
+func LoadExtensions(cfg config.Config) (ExtensionMap, map[string]error) {
+	extensionMap := make(ExtensionMap)
+	errs := make(map[string]error)
+
+	index, err := LoadIndex(indexPath(cfg))
+	if err != nil {
+		for alias := range cfg.Extensions {
+			errs[alias] = err
+		}
+		return extensionMap, errs
+	}
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		for alias := range cfg.Extensions {
+			errs[alias] = err
+		}
+		return extensionMap, errs
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for alias := range cfg.Extensions {
+		wg.Add(1)
+		go func(alias string) {
+			defer wg.Done()
+
+			extension, err := loadExtension(index, lockfile, cfg, alias)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[alias] = err
+				return
+			}
+			extensionMap[alias] = extension
+		}(alias)
+	}
+	wg.Wait()
+
+	if err := index.Save(); err != nil {
+		for alias := range extensionMap {
+			errs[alias] = err
+		}
+	}
+
+	if err := lockfile.Save(); err != nil {
+		for alias := range extensionMap {
+			errs[alias] = err
+		}
+	}
+
+	return extensionMap, errs
+}
+
+func loadExtension(index *Index, lockfile *Lockfile, cfg config.Config, alias string) (Extension, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return Extension{}, fmt.Errorf("extension %s not found", alias)
+	}
+
+	// replayed extensions are never downloaded nor run, their manifest is served from the fixture
+	if ReplayPath != "" {
+		manifest, err := replayManifest(alias)
 		if err != nil {
 			return Extension{}, err
 		}
 
 		return Extension{
-			Manifest:   manifest,
-			Entrypoint: entrypoint,
+			Alias:    alias,
+			Manifest: manifest,
+			Timeouts: NewTimeouts(cfg.ExtensionTimeouts(alias)),
+			Env:      NewEnvPolicy(extensionConfig),
 		}, nil
 	}
 
-	manifestBytes, err := os.ReadFile(manifestPath)
+	origin := resolveOrigin(cfg, extensionConfig.Origin)
+	extensionDir, err := ExtensionDir(origin)
 	if err != nil {
-		return Extension{}, fmt.Errorf("failed to read manifest: %w", err)
+		return Extension{}, err
 	}
-
-	var manifest sunbeam.Manifest
-	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
-		return Extension{}, fmt.Errorf("failed to decode manifest: %w", err)
+	entrypoint, validators, err := loadEntrypoint(origin, extensionDir, lockfile)
+	if err != nil {
+		return Extension{}, err
 	}
 
-	return Extension{
-		Manifest:   manifest,
+	extension := Extension{
+		Alias:      alias,
 		Entrypoint: entrypoint,
-	}, nil
-}
+		Timeouts:   NewTimeouts(cfg.ExtensionTimeouts(alias)),
+		Env:        NewEnvPolicy(extensionConfig),
+	}
+
+	if extensionConfig.Manifest != nil {
+		extension.Manifest = *extensionConfig.Manifest
+		if err := recordExtension(extension); err != nil {
+			return Extension{}, err
+		}
+
+		return extension, nil
+	}
 
-func cacheManifest(entrypoint string, manifestPath string) (sunbeam.Manifest, error) {
-	manifest, err := ExtractManifest(entrypoint)
+	modTime, err := manifestModTime(entrypoint)
 	if err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to extract manifest: %w", err)
+		return Extension{}, err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(manifestPath), 0755); err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to create directory: %w", err)
+	key := indexKey(origin, entrypoint)
+	if manifest, ok := index.Get(key, modTime); ok {
+		extension.Manifest = manifest
+		if err := recordExtension(extension); err != nil {
+			return Extension{}, err
+		}
+
+		return extension, nil
 	}
 
-	f, err := os.Create(manifestPath)
+	manifest, err := extension.indexManifest(index, key)
 	if err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to create manifest: %w", err)
+		return Extension{}, err
 	}
-	defer f.Close()
+	index.SetValidators(key, validators)
 
-	if err := json.NewEncoder(f).Encode(manifest); err != nil {
-		return sunbeam.Manifest{}, fmt.Errorf("failed to write manifest: %w", err)
+	// the entrypoint was verified against the lockfile, so the lock entry matches it
+	if entry, ok := lockfile.Get(origin); ok && IsRemote(origin) {
+		if err := archiveVersion(origin, entrypoint, entry, manifest, keepVersions(cfg)); err != nil {
+			return Extension{}, err
+		}
 	}
 
-	return manifest, nil
+	extension.Manifest = manifest
+	if err := recordExtension(extension); err != nil {
+		return Extension{}, err
+	}
+
+	return extension, nil
+}
+
+// recordExtension saves the manifest of the extension to the fixture when recording, so that it is served during replay
+func recordExtension(extension Extension) error {
+	if RecordPath == "" {
+		return nil
+	}
+
+	if err := recordManifest(extension.Alias, extension.Manifest); err != nil {
+		return fmt.Errorf("failed to record manifest: %w", err)
+	}
+
+	return nil
 }
 
-func Upgrade(extensionConfig config.ExtensionConfig) error {
-	hash, err := Hash(extensionConfig.Origin)
+// indexKey returns the key of an extension in the index: the url of remote extensions, the absolute path of local ones
+func indexKey(origin string, entrypoint string) string {
+	if IsRemote(origin) {
+		return origin
+	}
+
+	return entrypoint
+}
+
+func (e Extension) indexManifest(index *Index, key string) (sunbeam.Manifest, error) {
+	manifest, err := e.ExtractManifest()
 	if err != nil {
-		return err
+		return sunbeam.Manifest{}, fmt.Errorf("failed to extract manifest: %w", err)
 	}
 
-	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
-	manifestPath := filepath.Join(extensionDir, "manifest.json")
-	if IsRemote(extensionConfig.Origin) {
-		originUrl, err := url.Parse(extensionConfig.Origin)
-		if err != nil {
-			return fmt.Errorf("failed to parse origin: %w", err)
-		}
+	// the manifest extraction may chmod the entrypoint, so we need to stat it afterwards
+	modTime, err := manifestModTime(e.Entrypoint)
+	if err != nil {
+		return sunbeam.Manifest{}, err
+	}
 
-		entrypoint := filepath.Join(extensionDir, filepath.Base(originUrl.Path))
-		if err := DownloadEntrypoint(extensionConfig.Origin, entrypoint); err != nil {
-			return err
-		}
+	index.Set(key, IndexEntry{
+		Entrypoint: e.Entrypoint,
+		ModTime:    modTime,
+		Manifest:   manifest,
+	})
+
+	return manifest, nil
+}
 
-		if _, err := cacheManifest(entrypoint, manifestPath); err != nil {
-			return err
+// ExtractManifest reads the static manifest of the extension if there is one.
+// Otherwise the entrypoint is run, and the manifest timeout of the extension is enforced.
+func (e Extension) ExtractManifest() (sunbeam.Manifest, error) {
+	if manifest, ok, err := ReadStaticManifest(e.Entrypoint); err != nil {
+		return sunbeam.Manifest{}, err
+	} else if ok {
+		// keep the entrypoint executable, as when the manifest is extracted by running it
+		if err := os.Chmod(e.Entrypoint, 0755); err != nil {
+			return sunbeam.Manifest{}, err
 		}
 
-		return nil
+		return manifest, nil
 	}
 
-	entrypoint := extensionConfig.Origin
-	if strings.HasPrefix(entrypoint, "~") {
-		entrypoint = strings.Replace(entrypoint, "~", os.Getenv("HOME"), 1)
-	} else if !filepath.IsAbs(entrypoint) {
-		entrypoint = filepath.Join(filepath.Dir(config.Path), entrypoint)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.Timeouts.Manifest)
+	defer cancel()
 
-	if _, err := cacheManifest(entrypoint, manifestPath); err != nil {
-		return err
+	manifest, err := ExtractManifestContext(ctx, e.Entrypoint, e.Env.Environ())
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return sunbeam.Manifest{}, TimeoutError{Alias: e.Alias, Timeout: e.Timeouts.Manifest, Op: "extracting manifest"}
 	}
-	return nil
+
+	return manifest, err
 }
 
-func ExtractManifest(entrypoint string) (sunbeam.Manifest, error) {
+func ExtractManifestContext(ctx context.Context, entrypoint string, env []string) (sunbeam.Manifest, error) {
 	entrypoint, err := filepath.Abs(entrypoint)
 	if err != nil {
 		return sunbeam.Manifest{}, err
@@ -355,12 +764,12 @@ func ExtractManifest(entrypoint string) (sunbeam.Manifest, error) {
 		return sunbeam.Manifest{}, err
 	}
 
-	cmd := exec.Command(entrypoint)
+	cmd := exec.CommandContext(ctx, entrypoint)
 	cmd.Dir = filepath.Dir(entrypoint)
-	cmd.Env = os.Environ()
-	cmd.Env = append(cmd.Env, "SUNBEAM=1")
+	cmd.Env = env
+	cmd.WaitDelay = time.Second
 
-	manifestBytes, err := cmd.Output()
+	manifestBytes, err := captureOutput(cmd, "", "", "manifest", schemas.ValidateManifest)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return sunbeam.Manifest{}, fmt.Errorf("command failed: %s", stripansi.Strip(string(exitErr.Stderr)))
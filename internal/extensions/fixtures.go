@@ -0,0 +1,267 @@
+package extensions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/acarl005/stripansi"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// RecordPath is the fixture file every run of an extension is appended to, when set
+var RecordPath string
+
+// ReplayPath is the fixture file runs are served from instead of running the entrypoints, when set
+var ReplayPath string
+
+// Interaction is a run of an extension command, as saved in a fixture
+type Interaction struct {
+	Alias string `json:"alias"`
+	// Payload is the payload sent to the entrypoint, without the cwd since it differs between machines,
+	// and without the preferences since they may hold secrets
+	Payload  json.RawMessage `json:"payload"`
+	ExitCode int             `json:"exitCode"`
+	Stdout   string          `json:"stdout"`
+	Stderr   string          `json:"stderr,omitempty"`
+}
+
+type Fixture struct {
+	// Manifests are the manifests of the extensions loaded while recording, keyed by alias.
+	// They are served during replay, so that extensions are never downloaded nor run.
+	Manifests    map[string]sunbeam.Manifest `json:"manifests,omitempty"`
+	Interactions []Interaction               `json:"interactions"`
+}
+
+func LoadFixture(path string) (Fixture, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(bs, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+
+	// fixtures may be edited by hand, payloads are compared in their canonical form
+	for i, interaction := range fixture.Interactions {
+		payload, err := canonicalPayload(interaction.Payload)
+		if err != nil {
+			return Fixture{}, fmt.Errorf("invalid payload in fixture %s: %w", path, err)
+		}
+		fixture.Interactions[i].Payload = payload
+	}
+
+	return fixture, nil
+}
+
+func (f Fixture) Save(path string) error {
+	bs, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, bs, 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return nil
+}
+
+var recording sync.Mutex
+
+// recordInteraction appends a run to the fixture at RecordPath.
+// The fixture is saved after each run, so that nothing is lost if sunbeam is killed.
+func recordInteraction(alias string, cmd *exec.Cmd, stdout []byte, stderr []byte) error {
+	payload, err := fixturePayload(cmd)
+	if err != nil {
+		return err
+	}
+
+	recording.Lock()
+	defer recording.Unlock()
+
+	fixture, err := loadRecording()
+	if err != nil {
+		return err
+	}
+
+	fixture.Interactions = append(fixture.Interactions, Interaction{
+		Alias:    alias,
+		Payload:  payload,
+		ExitCode: exitCode(cmd),
+		Stdout:   string(stdout),
+		Stderr:   string(stderr),
+	})
+
+	return fixture.Save(RecordPath)
+}
+
+// recordManifest saves the manifest of an extension to the fixture at RecordPath, if it changed
+func recordManifest(alias string, manifest sunbeam.Manifest) error {
+	recording.Lock()
+	defer recording.Unlock()
+
+	fixture, err := loadRecording()
+	if err != nil {
+		return err
+	}
+
+	if recorded, ok := fixture.Manifests[alias]; ok {
+		a, _ := json.Marshal(recorded)
+		b, _ := json.Marshal(manifest)
+		if bytes.Equal(a, b) {
+			return nil
+		}
+	}
+
+	if fixture.Manifests == nil {
+		fixture.Manifests = make(map[string]sunbeam.Manifest)
+	}
+	fixture.Manifests[alias] = manifest
+
+	return fixture.Save(RecordPath)
+}
+
+// loadRecording reads the fixture at RecordPath, it is empty until the first run is recorded
+func loadRecording() (Fixture, error) {
+	if _, err := os.Stat(RecordPath); err != nil {
+		return Fixture{}, nil
+	}
+
+	return LoadFixture(RecordPath)
+}
+
+var replay struct {
+	once     sync.Once
+	mu       sync.Mutex
+	fixture  Fixture
+	err      error
+	consumed []bool
+}
+
+// replayInteraction returns the recorded run matching the command.
+// Identical runs are served in the order they were recorded, the last one is served again once they are all consumed.
+func replayInteraction(alias string, cmd *exec.Cmd) (Interaction, error) {
+	if err := loadReplay(); err != nil {
+		return Interaction{}, err
+	}
+
+	payload, err := fixturePayload(cmd)
+	if err != nil {
+		return Interaction{}, err
+	}
+
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+
+	last := -1
+	for i, interaction := range replay.fixture.Interactions {
+		if interaction.Alias != alias || !bytes.Equal(interaction.Payload, payload) {
+			continue
+		}
+
+		if !replay.consumed[i] {
+			replay.consumed[i] = true
+			return interaction, nil
+		}
+		last = i
+	}
+
+	if last == -1 {
+		return Interaction{}, fmt.Errorf("no interaction recorded for extension %s with payload %s", alias, payload)
+	}
+
+	return replay.fixture.Interactions[last], nil
+}
+
+// replayManifest returns the manifest recorded for an extension
+func replayManifest(alias string) (sunbeam.Manifest, error) {
+	if err := loadReplay(); err != nil {
+		return sunbeam.Manifest{}, err
+	}
+
+	manifest, ok := replay.fixture.Manifests[alias]
+	if !ok {
+		return sunbeam.Manifest{}, fmt.Errorf("no manifest recorded for extension %s", alias)
+	}
+
+	return manifest, nil
+}
+
+// loadReplay reads the fixture at ReplayPath once, it is shared by all the extensions
+func loadReplay() error {
+	replay.once.Do(func() {
+		replay.fixture, replay.err = LoadFixture(ReplayPath)
+		replay.consumed = make([]bool, len(replay.fixture.Interactions))
+	})
+
+	return replay.err
+}
+
+// replayOutput serves a recorded run, as captureOutput would return it
+func replayOutput(alias string, cmd *exec.Cmd) ([]byte, error) {
+	interaction, err := replayInteraction(alias, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if interaction.ExitCode != 0 {
+		return nil, fmt.Errorf("command failed: %s", stripansi.Strip(interaction.Stderr))
+	}
+
+	return []byte(interaction.Stdout), nil
+}
+
+// replayRun serves a recorded run, writing its output to the writers of the command
+func replayRun(alias string, cmd *exec.Cmd) error {
+	interaction, err := replayInteraction(alias, cmd)
+	if err != nil {
+		return err
+	}
+
+	for _, stream := range []struct {
+		w    io.Writer
+		data string
+	}{{cmd.Stdout, interaction.Stdout}, {cmd.Stderr, interaction.Stderr}} {
+		if stream.w == nil {
+			continue
+		}
+
+		if _, err := io.WriteString(stream.w, stream.data); err != nil {
+			return err
+		}
+	}
+
+	if interaction.ExitCode != 0 {
+		return fmt.Errorf("exit status %d", interaction.ExitCode)
+	}
+
+	return nil
+}
+
+// fixturePayload returns the payload of a command built by CmdContext, in its canonical form
+func fixturePayload(cmd *exec.Cmd) (json.RawMessage, error) {
+	if len(cmd.Args) < 2 {
+		return nil, fmt.Errorf("command has no payload")
+	}
+
+	return canonicalPayload([]byte(cmd.Args[1]))
+}
+
+func canonicalPayload(bs []byte) (json.RawMessage, error) {
+	var payload map[string]any
+	if err := json.Unmarshal(bs, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+	delete(payload, "cwd")
+	delete(payload, "preferences")
+
+	// maps are marshalled with sorted keys
+	return json.Marshal(payload)
+}
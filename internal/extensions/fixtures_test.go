@@ -0,0 +1,103 @@
+package extensions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const fixtureScript = `#!/bin/sh
+if [ $# -eq 0 ]; then
+	echo '{"title": "Fixture", "preferences": [{"name": "token", "title": "Token", "type": "string"}], "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}]}'
+	exit 0
+fi
+echo '{"text": "hi"}'
+`
+
+// setFixturePaths sets the record and replay paths for the duration of the test
+func setFixturePaths(t *testing.T, record string, replayPath string) {
+	t.Helper()
+
+	recordPath, replayPathBefore := RecordPath, ReplayPath
+	RecordPath, ReplayPath = record, replayPath
+	replay.once = sync.Once{}
+	t.Cleanup(func() {
+		RecordPath, ReplayPath = recordPath, replayPathBefore
+		replay.once = sync.Once{}
+	})
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := isolateCache(t)
+
+	entrypoint := filepath.Join(dir, "fixture.sh")
+	if err := os.WriteFile(entrypoint, []byte(fixtureScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := writeConfig(t, dir, map[string]config.ExtensionConfig{
+		"fixture": {Origin: entrypoint, Preferences: map[string]any{"token": "s3cr3t"}},
+	})
+
+	fixturePath := filepath.Join(dir, "fixture.json")
+	setFixturePaths(t, fixturePath, "")
+
+	extension, err := LoadExtension(cfg, "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded, err := extension.Output(sunbeam.Payload{Command: "hi", Preferences: map[string]any{"token": "s3cr3t"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bts, err := os.ReadFile(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(bts), "s3cr3t") {
+		t.Fatalf("expected the preferences to be redacted from the fixture:\n%s", bts)
+	}
+
+	fixture, err := LoadFixture(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fixture.Manifests["fixture"].Title != "Fixture" {
+		t.Fatalf("expected the manifest to be recorded, got %v", fixture.Manifests)
+	}
+
+	// the extension must be served from the fixture alone
+	if err := os.Remove(entrypoint); err != nil {
+		t.Fatal(err)
+	}
+	setFixturePaths(t, "", fixturePath)
+
+	extension, err = LoadExtension(cfg, "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if extension.Manifest.Title != "Fixture" {
+		t.Errorf("expected the recorded manifest, got %s", extension.Manifest.Title)
+	}
+
+	for _, preferences := range []map[string]any{{"token": "other"}, nil} {
+		replayed, err := extension.Output(sunbeam.Payload{Command: "hi", Preferences: preferences})
+		if err != nil {
+			t.Fatalf("failed to replay with preferences %v: %v", preferences, err)
+		}
+
+		if string(replayed) != string(recorded) {
+			t.Errorf("expected %s, got %s", recorded, replayed)
+		}
+	}
+}
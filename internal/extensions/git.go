@@ -0,0 +1,225 @@
+package extensions
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitOrigin is an extension stored in a git repository.
+// The syntax of the origin is git+<url>[?ref=<branch|tag|commit>]#<path>, where path is the entrypoint in the repository.
+type GitOrigin struct {
+	URL  string
+	Ref  string
+	Path string
+}
+
+func IsGit(origin string) bool {
+	return strings.HasPrefix(origin, "git+")
+}
+
+func ParseGitOrigin(origin string) (GitOrigin, error) {
+	if !IsGit(origin) {
+		return GitOrigin{}, fmt.Errorf("%s is not a git origin", origin)
+	}
+
+	originUrl, err := url.Parse(strings.TrimPrefix(origin, "git+"))
+	if err != nil {
+		return GitOrigin{}, fmt.Errorf("failed to parse origin: %w", err)
+	}
+
+	if originUrl.Fragment == "" {
+		return GitOrigin{}, fmt.Errorf("missing entrypoint in %s, use git+<url>#<path>", origin)
+	}
+
+	entrypoint := filepath.Clean(filepath.FromSlash(originUrl.Fragment))
+	if filepath.IsAbs(entrypoint) || entrypoint == ".." || strings.HasPrefix(entrypoint, ".."+string(filepath.Separator)) {
+		return GitOrigin{}, fmt.Errorf("entrypoint %s is outside of the repository", originUrl.Fragment)
+	}
+
+	query := originUrl.Query()
+	ref := query.Get("ref")
+	query.Del("ref")
+	if err := checkRef(ref); err != nil {
+		return GitOrigin{}, err
+	}
+
+	originUrl.RawQuery = query.Encode()
+	originUrl.Fragment = ""
+	originUrl.RawFragment = ""
+
+	return GitOrigin{
+		URL:  originUrl.String(),
+		Ref:  ref,
+		Path: entrypoint,
+	}, nil
+}
+
+// CloneRepository clones the repository of the origin, and checks out the given commit.
+// If commit is empty, the ref of the origin is resolved, following branches to their latest commit.
+// The commit checked out is returned.
+func CloneRepository(origin GitOrigin, target string, commit string) (string, error) {
+	if err := os.RemoveAll(target); err != nil {
+		return "", fmt.Errorf("failed to remove %s: %w", target, err)
+	}
+
+	if err := checkRef(commit); err != nil {
+		return "", err
+	}
+
+	if _, err := git("", "clone", "--quiet", "--no-checkout", "--", origin.URL, target); err != nil {
+		return "", fmt.Errorf("failed to clone %s: %w", origin.URL, err)
+	}
+
+	if commit == "" {
+		c, err := resolveRef(target, origin.Ref)
+		if err != nil {
+			return "", err
+		}
+		commit = c
+	}
+
+	if _, err := git(target, "checkout", "--quiet", "--detach", commit, "--"); err != nil {
+		return "", fmt.Errorf("failed to checkout %s: %w", commit, err)
+	}
+
+	return repositoryHead(target)
+}
+
+// loadGitEntrypoint clones the repository if needed, checking out the commit pinned in the lockfile if any
+func loadGitEntrypoint(origin string, extensionDir string, entrypoint string, lockfile *Lockfile) (string, error) {
+	repoDir := filepath.Join(extensionDir, "repo")
+	if _, err := os.Stat(repoDir); err == nil {
+		if err := lockfile.VerifyRepository(origin, repoDir, entrypoint); err != nil {
+			return "", err
+		}
+
+		return entrypoint, nil
+	}
+
+	if err := lockfile.CheckPinned(origin); err != nil {
+		return "", err
+	}
+
+	gitOrigin, err := ParseGitOrigin(origin)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(extensionDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	var commit string
+	if entry, ok := lockfile.Get(origin); ok {
+		commit = entry.Commit
+	}
+
+	if _, err := CloneRepository(gitOrigin, repoDir, commit); err != nil {
+		os.RemoveAll(repoDir)
+		return "", err
+	}
+
+	if _, err := os.Stat(entrypoint); err != nil {
+		os.RemoveAll(repoDir)
+		return "", fmt.Errorf("entrypoint %s not found in %s", gitOrigin.Path, gitOrigin.URL)
+	}
+
+	if err := lockfile.VerifyRepository(origin, repoDir, entrypoint); err != nil {
+		os.RemoveAll(repoDir)
+		return "", err
+	}
+
+	return entrypoint, nil
+}
+
+// CheckoutCommit checks out a commit of an existing clone
+func CheckoutCommit(repoDir string, commit string) error {
+	if err := checkRef(commit); err != nil {
+		return err
+	}
+
+	if _, err := git(repoDir, "checkout", "--quiet", "--detach", commit, "--"); err != nil {
+		return fmt.Errorf("failed to checkout %s: %w", commit, err)
+	}
+
+	return nil
+}
+
+// GitDiff returns the diff between two commits of a repository
+func GitDiff(repoDir string, from string, to string) (string, error) {
+	if err := checkRef(from); err != nil {
+		return "", err
+	}
+
+	if err := checkRef(to); err != nil {
+		return "", err
+	}
+
+	return git(repoDir, "diff", "--no-color", from, to, "--")
+}
+
+// checkRef rejects refs that git would parse as options
+func checkRef(ref string) error {
+	if strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("invalid ref %s", ref)
+	}
+
+	return nil
+}
+
+func resolveRef(repoDir string, ref string) (string, error) {
+	if ref == "" {
+		return repositoryHead(repoDir)
+	}
+
+	if err := checkRef(ref); err != nil {
+		return "", err
+	}
+
+	// remote branches take precedence, so that the branches of the origin are followed
+	for _, candidate := range []string{"refs/remotes/origin/" + ref, "refs/tags/" + ref, ref} {
+		if commit, err := git(repoDir, "rev-parse", "--verify", "--quiet", candidate+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+
+	return "", fmt.Errorf("ref %s not found", ref)
+}
+
+// repositoryHead returns the commit checked out in a repository.
+// The HEAD file is read directly when detached, to avoid spawning git every time an extension is loaded.
+func repositoryHead(repoDir string) (string, error) {
+	if bts, err := os.ReadFile(filepath.Join(repoDir, ".git", "HEAD")); err == nil {
+		if head := strings.TrimSpace(string(bts)); !strings.HasPrefix(head, "ref:") {
+			return head, nil
+		}
+	}
+
+	return git(repoDir, "rev-parse", "HEAD")
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// never prompt for credentials, sunbeam may be drawing a tui
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+		}
+
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,192 @@
+package extensions
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// testRepository is a local repository with two commits on main, a tag on the first one, and a dev branch on top of main
+type testRepository struct {
+	URL    string
+	First  string
+	Second string
+	Dev    string
+}
+
+func gitTest(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main", "-c", "commit.gpgsign=false"}, args...)
+	output, err := git(dir, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return output
+}
+
+func commitFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	fp := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(fp, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	gitTest(t, dir, "add", ".")
+	gitTest(t, dir, "commit", "--quiet", "-m", "update "+name)
+	return gitTest(t, dir, "rev-parse", "HEAD")
+}
+
+func newTestRepository(t *testing.T) testRepository {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	gitTest(t, dir, "init", "--quiet")
+	repo := testRepository{URL: "file://" + dir}
+	repo.First = commitFile(t, dir, "tools/ext.sh", "#!/bin/sh\necho v1\n")
+	gitTest(t, dir, "tag", "v1")
+	repo.Second = commitFile(t, dir, "tools/ext.sh", "#!/bin/sh\necho v2\n")
+
+	gitTest(t, dir, "checkout", "--quiet", "-b", "dev")
+	repo.Dev = commitFile(t, dir, "tools/ext.sh", "#!/bin/sh\necho dev\n")
+	gitTest(t, dir, "checkout", "--quiet", "main")
+
+	return repo
+}
+
+func TestParseGitOrigin(t *testing.T) {
+	origin, err := ParseGitOrigin("git+file:///tmp/repo?ref=v1#tools/ext.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if origin.URL != "file:///tmp/repo" || origin.Ref != "v1" || origin.Path != filepath.Join("tools", "ext.sh") {
+		t.Fatalf("unexpected origin: %+v", origin)
+	}
+
+	for _, invalid := range []string{
+		"git+file:///tmp/repo",
+		"git+file:///tmp/repo#../ext.sh",
+		"git+file:///tmp/repo?ref=--upload-pack=touch#ext.sh",
+	} {
+		if _, err := ParseGitOrigin(invalid); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestCloneRepository(t *testing.T) {
+	repo := newTestRepository(t)
+
+	for _, tc := range []struct {
+		name   string
+		ref    string
+		commit string
+		want   string
+	}{
+		{name: "default branch", want: repo.Second},
+		{name: "branch", ref: "dev", want: repo.Dev},
+		{name: "tag", ref: "v1", want: repo.First},
+		{name: "commit", ref: repo.First, want: repo.First},
+		{name: "pinned commit", ref: "dev", commit: repo.Second, want: repo.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "clone")
+			commit, err := CloneRepository(GitOrigin{URL: repo.URL, Ref: tc.ref, Path: "tools/ext.sh"}, target, tc.commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if commit != tc.want {
+				t.Fatalf("expected commit %s, got %s", tc.want, commit)
+			}
+		})
+	}
+}
+
+func TestCloneRepositoryRejectsOptions(t *testing.T) {
+	repo := newTestRepository(t)
+
+	marker := filepath.Join(t.TempDir(), "marker")
+	target := filepath.Join(t.TempDir(), "clone")
+	if _, err := CloneRepository(GitOrigin{URL: "--upload-pack=touch " + marker, Path: "ext.sh"}, target, ""); err == nil {
+		t.Fatal("expected the clone to fail")
+	}
+
+	if _, err := CloneRepository(GitOrigin{URL: repo.URL, Path: "ext.sh"}, target, "--orphan=x"); err == nil {
+		t.Fatal("expected the commit to be rejected")
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("the url was parsed as an option")
+	}
+}
+
+func TestLoadGitEntrypoint(t *testing.T) {
+	repo := newTestRepository(t)
+	origin := "git+" + repo.URL + "?ref=dev#tools/ext.sh"
+	extensionDir := t.TempDir()
+
+	entrypoint, err := ResolveEntrypoint(origin, extensionDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entrypoint != filepath.Join(extensionDir, "repo", "tools", "ext.sh") {
+		t.Fatalf("unexpected entrypoint %s", entrypoint)
+	}
+
+	// the commit pinned in the lockfile wins over the latest commit of the branch
+	lockfile, err := LoadLockfile(filepath.Join(t.TempDir(), "sunbeam.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checksum := func(content string) string {
+		fp := filepath.Join(t.TempDir(), "ext.sh")
+		if err := os.WriteFile(fp, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		sum, err := Sha256(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sum
+	}
+	lockfile.Set(origin, LockEntry{Commit: repo.First, Sha256: checksum("#!/bin/sh\necho v1\n")})
+
+	if _, err := loadGitEntrypoint(origin, extensionDir, entrypoint, lockfile); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(entrypoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "#!/bin/sh\necho v1\n" {
+		t.Fatalf("expected the pinned version, got %q", content)
+	}
+
+	// a clone that does not match the lockfile is refused
+	lockfile.Set(origin, LockEntry{Commit: repo.Dev, Sha256: checksum("#!/bin/sh\necho dev\n")})
+	if _, err := loadGitEntrypoint(origin, extensionDir, entrypoint, lockfile); err == nil {
+		t.Fatal("expected an integrity error")
+	}
+}
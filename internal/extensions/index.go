@@ -0,0 +1,166 @@
+package extensions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+var IndexPath = filepath.Join(utils.CacheDir(), "extensions", "index.json")
+
+// Index caches the manifests of all extensions in a single file.
+// Entries are keyed by origin, and are only valid as long as the modification time of the entrypoint does not change.
+type Index struct {
+	mu      sync.Mutex
+	path    string
+	dirty   bool
+	entries map[string]IndexEntry
+}
+
+type IndexEntry struct {
+	Entrypoint string           `json:"entrypoint"`
+	ModTime    time.Time        `json:"modTime"`
+	Manifest   sunbeam.Manifest `json:"manifest"`
+
+	// the validators of the last download of remote extensions, used to check for updates with conditional requests
+	CacheValidators
+}
+
+func LoadIndex(indexPath string) (*Index, error) {
+	index := &Index{
+		path:    indexPath,
+		entries: make(map[string]IndexEntry),
+	}
+
+	bts, err := os.ReadFile(indexPath)
+	if os.IsNotExist(err) {
+		return index, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	// a corrupted index is not fatal, the manifests will be extracted again
+	if err := json.Unmarshal(bts, &index.entries); err != nil {
+		index.entries = make(map[string]IndexEntry)
+		index.dirty = true
+	}
+
+	return index, nil
+}
+
+func (idx *Index) Get(origin string, modTime time.Time) (sunbeam.Manifest, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.entries[origin]
+	if !ok || !entry.ModTime.Equal(modTime) {
+		return sunbeam.Manifest{}, false
+	}
+
+	return entry.Manifest, true
+}
+
+func (idx *Index) Set(origin string, entry IndexEntry) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.entries[origin] = entry
+	idx.dirty = true
+}
+
+// Validators returns the cache validators of an entry, they are empty if the entry is missing
+func (idx *Index) Validators(origin string) CacheValidators {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	return idx.entries[origin].CacheValidators
+}
+
+// SetValidators updates the cache validators of an existing entry
+func (idx *Index) SetValidators(origin string, validators CacheValidators) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	entry, ok := idx.entries[origin]
+	if !ok || entry.CacheValidators == validators {
+		return
+	}
+
+	entry.CacheValidators = validators
+	idx.entries[origin] = entry
+	idx.dirty = true
+}
+
+// Keys returns the keys of the index, sorted
+func (idx *Index) Keys() []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	keys := make([]string, 0, len(idx.entries))
+	for key := range idx.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func (idx *Index) Delete(origin string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if _, ok := idx.entries[origin]; !ok {
+		return
+	}
+
+	delete(idx.entries, origin)
+	idx.dirty = true
+}
+
+func (idx *Index) Save() error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if !idx.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	bts, err := json.Marshal(idx.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode index: %w", err)
+	}
+
+	// write to a temporary file first, so that concurrent sunbeam processes never read a partial index
+	f, err := os.CreateTemp(filepath.Dir(idx.path), "index-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(bts); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close index: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), idx.path); err != nil {
+		return fmt.Errorf("failed to write index: %w", err)
+	}
+
+	idx.dirty = false
+	return nil
+}
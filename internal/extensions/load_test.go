@@ -0,0 +1,209 @@
+package extensions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+const benchmarkExtensions = 20
+
+// isolateCache moves the cache and the index to a temporary directory, which is returned
+func isolateCache(tb testing.TB) string {
+	tb.Helper()
+
+	dir := tb.TempDir()
+	tb.Setenv("XDG_CACHE_HOME", filepath.Join(dir, "cache"))
+
+	indexPath := IndexPath
+	IndexPath = filepath.Join(dir, "cache", "sunbeam", "extensions", "index.json")
+	tb.Cleanup(func() {
+		IndexPath = indexPath
+	})
+
+	return dir
+}
+
+// writeConfig writes the extensions to a config in dir, and loads it
+func writeConfig(tb testing.TB, dir string, origins map[string]config.ExtensionConfig) config.Config {
+	tb.Helper()
+
+	bts, err := json.Marshal(config.Config{Extensions: origins})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	configPath := filepath.Join(dir, "sunbeam.json")
+	if err := os.WriteFile(configPath, bts, 0644); err != nil {
+		tb.Fatal(err)
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return cfg
+}
+
+// setupExtensions writes a config with n local extensions, and isolates the cache and the index in a temporary directory
+func setupExtensions(tb testing.TB, n int) config.Config {
+	tb.Helper()
+
+	dir := isolateCache(tb)
+	origins := make(map[string]config.ExtensionConfig)
+	for i := 0; i < n; i++ {
+		alias := fmt.Sprintf("ext%d", i)
+		entrypoint := filepath.Join(dir, alias+".sh")
+		script := fmt.Sprintf(`#!/bin/sh
+echo '{"title": "%s", "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}]}'
+`, alias)
+		if err := os.WriteFile(entrypoint, []byte(script), 0755); err != nil {
+			tb.Fatal(err)
+		}
+
+		origins[alias] = config.ExtensionConfig{Origin: entrypoint}
+	}
+
+	return writeConfig(tb, dir, origins)
+}
+
+func TestLoadExtensions(t *testing.T) {
+	cfg := setupExtensions(t, 3)
+
+	extensionMap, errs := LoadExtensions(cfg)
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if len(extensionMap) != 3 {
+		t.Fatalf("expected 3 extensions, got %d", len(extensionMap))
+	}
+
+	index, err := LoadIndex(IndexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keys := index.Keys(); len(keys) != 3 {
+		t.Fatalf("expected 3 index entries, got %d", len(keys))
+	}
+
+	for alias, extension := range extensionMap {
+		if extension.Manifest.Title != alias {
+			t.Errorf("expected title %s, got %s", alias, extension.Manifest.Title)
+		}
+	}
+}
+
+func TestLoadExtensionsTimeout(t *testing.T) {
+	dir := isolateCache(t)
+
+	hung := filepath.Join(dir, "hung.sh")
+	if err := os.WriteFile(hung, []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	healthy := filepath.Join(dir, "healthy.sh")
+	if err := os.WriteFile(healthy, []byte(`#!/bin/sh
+echo '{"title": "Healthy", "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}]}'
+`), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := writeConfig(t, dir, map[string]config.ExtensionConfig{
+		"hung":    {Origin: hung, Timeouts: &config.Timeouts{Manifest: 1}},
+		"healthy": {Origin: healthy},
+	})
+
+	start := time.Now()
+	extensionMap, errs := LoadExtensions(cfg)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected the hung extension to be stopped after its timeout, took %s", elapsed)
+	}
+
+	var timeoutErr TimeoutError
+	if !errors.As(errs["hung"], &timeoutErr) {
+		t.Fatalf("expected a timeout error, got %v", errs["hung"])
+	}
+
+	if timeoutErr.Alias != "hung" || !strings.Contains(timeoutErr.Error(), "hung") {
+		t.Errorf("expected the error to name the extension, got %s", timeoutErr)
+	}
+
+	if _, ok := extensionMap["healthy"]; !ok || len(errs) != 1 {
+		t.Errorf("expected the other extensions to load, got %v", errs)
+	}
+}
+
+func TestNewTimeouts(t *testing.T) {
+	timeouts := NewTimeouts(config.Timeouts{})
+	if timeouts.Run != 0 {
+		t.Errorf("expected runs not to be bounded by default, got %s", timeouts.Run)
+	}
+
+	if timeouts.Output() != DefaultOutputTimeout || timeouts.Manifest != DefaultManifestTimeout {
+		t.Errorf("expected the default timeouts, got %s and %s", timeouts.Output(), timeouts.Manifest)
+	}
+
+	timeouts = NewTimeouts(config.Timeouts{Run: 5})
+	if timeouts.Run != 5*time.Second || timeouts.Output() != 5*time.Second {
+		t.Errorf("expected the configured run timeout to bound runs and outputs, got %s and %s", timeouts.Run, timeouts.Output())
+	}
+}
+
+// BenchmarkLoadExtensionsSequential loads the extensions one by one without an index, as startup used to
+func BenchmarkLoadExtensionsSequential(b *testing.B) {
+	cfg := setupExtensions(b, benchmarkExtensions)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		os.Remove(IndexPath)
+		b.StartTimer()
+
+		for alias := range cfg.Extensions {
+			if _, err := LoadExtension(cfg, alias); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkLoadExtensionsConcurrent loads the extensions concurrently, with an empty index
+func BenchmarkLoadExtensionsConcurrent(b *testing.B) {
+	cfg := setupExtensions(b, benchmarkExtensions)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		os.Remove(IndexPath)
+		b.StartTimer()
+
+		if _, errs := LoadExtensions(cfg); len(errs) > 0 {
+			b.Fatal(errs)
+		}
+	}
+}
+
+// BenchmarkLoadExtensionsIndexed loads the extensions from a warm index, which is the common case at startup
+func BenchmarkLoadExtensionsIndexed(b *testing.B) {
+	cfg := setupExtensions(b, benchmarkExtensions)
+	if _, errs := LoadExtensions(cfg); len(errs) > 0 {
+		b.Fatal(errs)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, errs := LoadExtensions(cfg); len(errs) > 0 {
+			b.Fatal(errs)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package extensions
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+// Frozen refuses to download remote extensions that are not pinned in the lockfile, or whose checksum changed
+var Frozen = len(os.Getenv("SUNBEAM_FROZEN")) > 0
+
+// LockPath returns the path of the lockfile, stored next to the config
+func LockPath(cfg config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Path()), "sunbeam.lock")
+}
+
+// Lockfile records the checksum of the entrypoint of each remote extension, keyed by origin.
+// The commit checked out is recorded as well for git extensions.
+type Lockfile struct {
+	mu      sync.Mutex
+	path    string
+	dirty   bool
+	entries map[string]LockEntry
+}
+
+type LockEntry struct {
+	Sha256 string `json:"sha256"`
+	Commit string `json:"commit,omitempty"`
+}
+
+type IntegrityError struct {
+	Origin   string
+	Kind     string
+	Expected string
+	Actual   string
+}
+
+func (e IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for %s: expected %s %s, got %s", e.Origin, e.Kind, e.Expected, e.Actual)
+}
+
+func LoadLockfile(lockPath string) (*Lockfile, error) {
+	lockfile := &Lockfile{
+		path:    lockPath,
+		entries: make(map[string]LockEntry),
+	}
+
+	bts, err := os.ReadFile(lockPath)
+	if os.IsNotExist(err) {
+		return lockfile, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	var content struct {
+		Extensions map[string]LockEntry `json:"extensions"`
+	}
+	if err := json.Unmarshal(bts, &content); err != nil {
+		return nil, fmt.Errorf("failed to decode lockfile: %w", err)
+	}
+
+	if content.Extensions != nil {
+		lockfile.entries = content.Extensions
+	}
+
+	return lockfile, nil
+}
+
+func (l *Lockfile) Get(origin string) (LockEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[origin]
+	return entry, ok
+}
+
+func (l *Lockfile) Set(origin string, entry LockEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[origin] = entry
+	l.dirty = true
+}
+
+// CheckPinned returns an error if the origin is not pinned while in frozen mode
+func (l *Lockfile) CheckPinned(origin string) error {
+	if !Frozen {
+		return nil
+	}
+
+	if _, ok := l.Get(origin); !ok {
+		return fmt.Errorf("%s is not pinned in %s, refusing to download it in frozen mode", origin, l.path)
+	}
+
+	return nil
+}
+
+// Verify compares the checksum of the entrypoint with the one recorded in the lockfile.
+// Unpinned entrypoints are pinned, unless frozen mode is enabled.
+func (l *Lockfile) Verify(origin string, entrypoint string) error {
+	checksum, err := Sha256(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	return l.verify(origin, LockEntry{Sha256: checksum})
+}
+
+// VerifyRepository compares the commit checked out in the repository and the checksum of the entrypoint with the ones recorded in the lockfile
+func (l *Lockfile) VerifyRepository(origin string, repoDir string, entrypoint string) error {
+	commit, err := repositoryHead(repoDir)
+	if err != nil {
+		return err
+	}
+
+	checksum, err := Sha256(entrypoint)
+	if err != nil {
+		return err
+	}
+
+	return l.verify(origin, LockEntry{Sha256: checksum, Commit: commit})
+}
+
+func (l *Lockfile) verify(origin string, actual LockEntry) error {
+	entry, ok := l.Get(origin)
+	if !ok {
+		if err := l.CheckPinned(origin); err != nil {
+			return err
+		}
+
+		l.Set(origin, actual)
+		return nil
+	}
+
+	if entry.Commit != actual.Commit {
+		return IntegrityError{Origin: origin, Kind: "commit", Expected: entry.Commit, Actual: actual.Commit}
+	}
+
+	if entry.Sha256 != actual.Sha256 {
+		return IntegrityError{Origin: origin, Kind: "sha256", Expected: entry.Sha256, Actual: actual.Sha256}
+	}
+
+	return nil
+}
+
+func (l *Lockfile) Save() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.dirty {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(map[string]any{
+		"extensions": l.entries,
+	}); err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// write to a temporary file first, so that a crash or a concurrent install never leaves a truncated lockfile
+	f, err := os.CreateTemp(filepath.Dir(l.path), "sunbeam-*.lock")
+	if err != nil {
+		return fmt.Errorf("failed to create lockfile: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close lockfile: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), l.path); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	l.dirty = false
+	return nil
+}
+
+func Sha256(fp string) (string, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
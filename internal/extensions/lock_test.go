@@ -0,0 +1,41 @@
+package extensions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockfileSave(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "sunbeam.lock")
+
+	lockfile, err := LoadLockfile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lockfile.Set("https://example.com/ext.sh", LockEntry{Sha256: "abc"})
+	if err := lockfile.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected only the lockfile in %s, got %d files", dir, len(entries))
+	}
+
+	loaded, err := LoadLockfile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := loaded.Get("https://example.com/ext.sh")
+	if !ok || entry.Sha256 != "abc" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+}
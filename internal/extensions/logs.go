@@ -0,0 +1,322 @@
+package extensions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+)
+
+// MaxLogSize is the size above which the log of an extension is rotated
+const MaxLogSize = 1 << 20
+
+// MaxLogStderr is the size above which the stderr of a run is truncated in the log
+const MaxLogStderr = 64 << 10
+
+// MaxLogFiles is the number of log files kept per extension, including the current one
+const MaxLogFiles = 3
+
+// LogEntry is a run of an extension, as written in its log
+type LogEntry struct {
+	Time     time.Time     `json:"time"`
+	Alias    string        `json:"alias"`
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exitCode"`
+	Duration time.Duration `json:"duration"`
+	Stderr   string        `json:"stderr,omitempty"`
+}
+
+func LogsDir() string {
+	return filepath.Join(utils.CacheDir(), "logs")
+}
+
+// LogPath returns the path of the current log of an extension, rotated logs are suffixed with their generation.
+// Aliases that would escape the logs directory are rejected.
+func LogPath(alias string) (string, error) {
+	if alias == "" || alias == "." || strings.ContainsAny(alias, `/\`) || strings.Contains(alias, "..") {
+		return "", fmt.Errorf("invalid alias %s", alias)
+	}
+
+	return filepath.Join(LogsDir(), alias+".log"), nil
+}
+
+// WriteLog appends an entry to the log of its extension, the log is rotated first if it is too large
+func WriteLog(entry LogEntry) error {
+	if len(entry.Stderr) > MaxLogStderr {
+		entry.Stderr = entry.Stderr[:MaxLogStderr] + "\n[truncated]"
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	logPath, err := LogPath(entry.Alias)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(LogsDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create logs directory: %w", err)
+	}
+
+	if info, err := os.Stat(logPath); err == nil && info.Size()+int64(len(line)) > MaxLogSize {
+		if err := rotateLog(logPath); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to write log: %w", err)
+	}
+
+	return nil
+}
+
+func rotateLog(logPath string) error {
+	for i := MaxLogFiles - 1; i > 0; i-- {
+		src := logPath
+		if i > 1 {
+			src = fmt.Sprintf("%s.%d", logPath, i-1)
+		}
+
+		if err := os.Rename(src, fmt.Sprintf("%s.%d", logPath, i)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate log: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RunCmd runs a command of the extension and writes the run to its log.
+// The stderr of the command is still forwarded to its writer, if there is one.
+func (e Extension) RunCmd(cmd *exec.Cmd, command string) error {
+	if ReplayPath != "" {
+		return replayRun(e.Alias, cmd)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = teeWriter(cmd.Stdout, &stdout)
+	cmd.Stderr = teeWriter(cmd.Stderr, &stderr)
+
+	startedAt := time.Now()
+	err := cmd.Run()
+
+	if RecordPath != "" {
+		if err := recordInteraction(e.Alias, cmd, stdout.Bytes(), stderr.Bytes()); err != nil {
+			return fmt.Errorf("failed to record interaction: %w", err)
+		}
+	}
+
+	_ = WriteLog(LogEntry{
+		Time:     startedAt,
+		Alias:    e.Alias,
+		Command:  command,
+		ExitCode: exitCode(cmd),
+		Duration: time.Since(startedAt),
+		Stderr:   stderr.String(),
+	})
+
+	return err
+}
+
+// teeWriter returns a writer duplicating its writes to the buffer, w may be nil
+func teeWriter(w io.Writer, buf *bytes.Buffer) io.Writer {
+	if w == nil {
+		return buf
+	}
+
+	return io.MultiWriter(w, buf)
+}
+
+// exitCode returns the exit code of a command that was run, or -1 if it could not be started
+func exitCode(cmd *exec.Cmd) int {
+	if cmd.ProcessState == nil {
+		return -1
+	}
+
+	return cmd.ProcessState.ExitCode()
+}
+
+// logPaths returns the current logs of an extension, or of every extension if alias is empty
+func logPaths(alias string) ([]string, error) {
+	if alias != "" {
+		logPath, err := LogPath(alias)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{logPath}, nil
+	}
+
+	return filepath.Glob(filepath.Join(LogsDir(), "*.log"))
+}
+
+// readLogs reads every generation of the logs, oldest run first, and returns the offset of the end of the current ones
+func readLogs(alias string) ([]LogEntry, map[string]followedLog, error) {
+	paths, err := logPaths(alias)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []LogEntry
+	logs := make(map[string]followedLog)
+	for _, logPath := range paths {
+		for i := MaxLogFiles - 1; i > 0; i-- {
+			fileEntries, _, err := readLogFile(fmt.Sprintf("%s.%d", logPath, i), 0)
+			if err != nil {
+				return nil, nil, err
+			}
+			entries = append(entries, fileEntries...)
+		}
+
+		info, err := os.Stat(logPath)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to stat log: %w", err)
+		}
+
+		fileEntries, offset, err := readLogFile(logPath, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, fileEntries...)
+		logs[logPath] = followedLog{info: info, offset: offset}
+	}
+
+	sortLogEntries(entries)
+	return entries, logs, nil
+}
+
+func sortLogEntries(entries []LogEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+}
+
+// LogFollower returns the runs appended to the logs since its last read.
+// The offset of each log is tracked, timestamps would miss the runs started at the same time, or before a clock change.
+type LogFollower struct {
+	alias string
+	logs  map[string]followedLog
+}
+
+type followedLog struct {
+	info   os.FileInfo
+	offset int64
+}
+
+// FollowLogs returns the logged runs of an extension, or of every extension if alias is empty, oldest first.
+// The follower returns the runs logged after them.
+func FollowLogs(alias string) (*LogFollower, []LogEntry, error) {
+	entries, logs, err := readLogs(alias)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &LogFollower{alias: alias, logs: logs}, entries, nil
+}
+
+// Next returns the runs written since the previous call, oldest first
+func (f *LogFollower) Next() ([]LogEntry, error) {
+	paths, err := logPaths(f.alias)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []LogEntry
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to stat log: %w", err)
+		}
+
+		// logs created since the last read are read from the start
+		followed, ok := f.logs[path]
+		offset := followed.offset
+		if !ok {
+			offset = 0
+		} else if !os.SameFile(followed.info, info) {
+			// the log was rotated, the end of the file that was followed is now the first generation
+			rotated, _, err := readLogFile(path+".1", followed.offset)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, rotated...)
+			offset = 0
+		}
+
+		fileEntries, offset, err := readLogFile(path, offset)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, fileEntries...)
+
+		f.logs[path] = followedLog{info: info, offset: offset}
+	}
+
+	sortLogEntries(entries)
+	return entries, nil
+}
+
+// readLogFile reads the complete lines of a log from offset, and returns the offset following the last one.
+// A line being written is left to the next read.
+func readLogFile(path string, offset int64) ([]LogEntry, int64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, offset, nil
+	} else if err != nil {
+		return nil, offset, fmt.Errorf("failed to open log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, offset, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	var entries []LogEntry
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, offset, fmt.Errorf("failed to read log: %w", err)
+		}
+		offset += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		// a line may be truncated if sunbeam was killed while writing it
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, offset, nil
+}
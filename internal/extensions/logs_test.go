@@ -0,0 +1,120 @@
+package extensions
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogPath(t *testing.T) {
+	for _, alias := range []string{"", ".", "..", "../evil", "a/b", `a\b`, "a..b"} {
+		if _, err := LogPath(alias); err == nil {
+			t.Errorf("expected alias %q to be rejected", alias)
+		}
+	}
+
+	if _, err := LogPath("github-stars"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFollowLogs(t *testing.T) {
+	isolateCache(t)
+
+	now := time.Now()
+	if err := WriteLog(LogEntry{Time: now, Alias: "ext", Command: "before"}); err != nil {
+		t.Fatal(err)
+	}
+
+	follower, entries, err := FollowLogs("ext")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Command != "before" {
+		t.Fatalf("expected the existing run, got %v", entries)
+	}
+
+	// runs sharing the timestamp of the last one printed must not be skipped
+	for _, command := range []string{"same-time", "earlier"} {
+		entry := LogEntry{Time: now, Alias: "ext", Command: command}
+		if command == "earlier" {
+			entry.Time = now.Add(-time.Hour)
+		}
+
+		if err := WriteLog(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err = follower.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 new runs, got %v", entries)
+	}
+
+	entries, err = follower.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 0 {
+		t.Fatalf("expected no new run, got %v", entries)
+	}
+}
+
+func TestFollowLogsRotation(t *testing.T) {
+	isolateCache(t)
+
+	if err := WriteLog(LogEntry{Time: time.Now(), Alias: "ext", Command: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	follower, _, err := FollowLogs("ext")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteLog(LogEntry{Time: time.Now(), Alias: "ext", Command: "before-rotation"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a run with a large stderr fills the log, so that the next one rotates it
+	if err := WriteLog(LogEntry{Time: time.Now(), Alias: "ext", Command: "large", Stderr: strings.Repeat("x", MaxLogStderr)}); err != nil {
+		t.Fatal(err)
+	}
+
+	logPath, err := LogPath("ext")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		info, err := os.Stat(logPath + ".1")
+		if err == nil && info.Size() > 0 {
+			break
+		}
+
+		if err := WriteLog(LogEntry{Time: time.Now(), Alias: "ext", Command: "large", Stderr: strings.Repeat("x", MaxLogStderr)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := follower.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) < 2 || entries[0].Command != "before-rotation" {
+		t.Fatalf("expected the runs written before the rotation to be returned first, got %d runs", len(entries))
+	}
+
+	last := entries[len(entries)-1]
+	if last.Command != "large" {
+		t.Errorf("expected the run written after the rotation to be returned, got %s", last.Command)
+	}
+}
@@ -0,0 +1,134 @@
+package extensions
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pomdtr/sunbeam/internal/schemas"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const (
+	// ManifestSuffix is appended to the entrypoint to get the path of its static manifest
+	ManifestSuffix = ".manifest.json"
+	// ManifestStartMarker and ManifestEndMarker delimit a static manifest embedded in the comments of the entrypoint header
+	ManifestStartMarker = "sunbeam:manifest"
+	ManifestEndMarker   = "sunbeam:end"
+)
+
+// manifestHeaderSize is the number of bytes of the entrypoint searched for an embedded manifest
+const manifestHeaderSize = 64 * 1024
+
+// ReadStaticManifest reads the manifest of an entrypoint without executing it.
+// The sidecar file takes precedence over the manifest embedded in the header of the entrypoint.
+// It returns false if the entrypoint has no static manifest.
+func ReadStaticManifest(entrypoint string) (sunbeam.Manifest, bool, error) {
+	manifestBytes, err := os.ReadFile(entrypoint + ManifestSuffix)
+	if os.IsNotExist(err) {
+		manifestBytes, err = readHeaderManifest(entrypoint)
+		if err != nil {
+			return sunbeam.Manifest{}, false, err
+		}
+
+		if manifestBytes == nil {
+			return sunbeam.Manifest{}, false, nil
+		}
+	} else if err != nil {
+		return sunbeam.Manifest{}, false, fmt.Errorf("failed to read static manifest: %w", err)
+	}
+
+	if err := schemas.ValidateManifest(manifestBytes); err != nil {
+		return sunbeam.Manifest{}, false, fmt.Errorf("invalid static manifest: %w", err)
+	}
+
+	var manifest sunbeam.Manifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return sunbeam.Manifest{}, false, fmt.Errorf("failed to decode static manifest: %w", err)
+	}
+
+	return manifest, true, nil
+}
+
+// readHeaderManifest extracts the manifest embedded in the comments at the top of the entrypoint:
+//
+//	# sunbeam:manifest
+//	# {"title": "My Extension", "commands": []}
+//	# sunbeam:end
+//
+// Any line comment prefix can be used, as long as it is the same on every line.
+func readHeaderManifest(entrypoint string) ([]byte, error) {
+	f, err := os.Open(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(io.LimitReader(f, manifestHeaderSize))
+	scanner.Buffer(make([]byte, 0, 4096), manifestHeaderSize)
+
+	var prefix string
+	var lines []string
+	inManifest := false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if !inManifest {
+			idx := strings.Index(line, ManifestStartMarker)
+			if idx < 0 || strings.TrimSpace(line[idx+len(ManifestStartMarker):]) != "" {
+				continue
+			}
+
+			// only comment markers are allowed before the start marker, so that code mentioning it is ignored
+			prefix = strings.TrimSpace(line[:idx])
+			if prefix == "" || strings.IndexFunc(prefix, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+				continue
+			}
+
+			inManifest = true
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, prefix) {
+			return nil, fmt.Errorf("invalid static manifest: line %q does not start with %q", line, prefix)
+		}
+
+		content := strings.TrimPrefix(trimmed, prefix)
+		if strings.TrimSpace(content) == ManifestEndMarker {
+			return []byte(strings.Join(lines, "\n")), nil
+		}
+
+		lines = append(lines, content)
+	}
+
+	if err := scanner.Err(); err != nil && err != bufio.ErrTooLong {
+		return nil, err
+	}
+
+	if inManifest {
+		return nil, fmt.Errorf("invalid static manifest: missing %s marker", ManifestEndMarker)
+	}
+
+	return nil, nil
+}
+
+// manifestModTime returns the modification time used to invalidate the index: the latest of the entrypoint and its sidecar manifest
+func manifestModTime(entrypoint string) (time.Time, error) {
+	entrypointInfo, err := os.Stat(entrypoint)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	modTime := entrypointInfo.ModTime()
+	if sidecarInfo, err := os.Stat(entrypoint + ManifestSuffix); err == nil && sidecarInfo.ModTime().After(modTime) {
+		modTime = sidecarInfo.ModTime()
+	}
+
+	return modTime, nil
+}
@@ -0,0 +1,175 @@
+package extensions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+// CacheValidators are the headers sent back in conditional requests, to check if a remote file changed without downloading it again
+type CacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+func responseValidators(resp *http.Response) CacheValidators {
+	return CacheValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+}
+
+// OutdatedResult compares the installed version of a remote extension with the upstream one.
+// Versions are checksums, or commits for git extensions.
+type OutdatedResult struct {
+	Alias    string `json:"alias"`
+	Origin   string `json:"origin"`
+	Current  string `json:"current"`
+	Latest   string `json:"latest"`
+	Outdated bool   `json:"outdated"`
+}
+
+// CheckOutdated checks if a remote extension changed upstream, without modifying the installed version.
+// Urls and bundles are checked with a conditional request, using the validators of the last download.
+// Git extensions are checked with git ls-remote.
+func CheckOutdated(cfg config.Config, alias string, index *Index, lockfile *Lockfile) (OutdatedResult, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return OutdatedResult{}, fmt.Errorf("extension %s not found", alias)
+	}
+
+	if !IsRemote(extensionConfig.Origin) {
+		return OutdatedResult{}, fmt.Errorf("extension %s is not a remote extension", alias)
+	}
+
+	result := OutdatedResult{
+		Alias:  alias,
+		Origin: extensionConfig.Origin,
+	}
+
+	extensionDir, err := ExtensionDir(extensionConfig.Origin)
+	if err != nil {
+		return OutdatedResult{}, err
+	}
+
+	current, ok := lockfile.Get(extensionConfig.Origin)
+	if !ok {
+		if IsGit(extensionConfig.Origin) {
+			current.Commit, _ = repositoryHead(filepath.Join(extensionDir, "repo"))
+		} else if file, err := archivedFile(extensionConfig.Origin, extensionDir); err == nil {
+			current.Sha256, _ = Sha256(file)
+		}
+	}
+
+	if IsGit(extensionConfig.Origin) {
+		gitOrigin, err := ParseGitOrigin(extensionConfig.Origin)
+		if err != nil {
+			return OutdatedResult{}, err
+		}
+
+		latest, err := remoteCommit(gitOrigin)
+		if err != nil {
+			return OutdatedResult{}, err
+		}
+
+		// commits are not advertised by ls-remote, a pinned commit never changes
+		if latest == "" && gitOrigin.Ref != "" && strings.HasPrefix(current.Commit, gitOrigin.Ref) {
+			latest = current.Commit
+		}
+
+		if latest == "" {
+			return OutdatedResult{}, fmt.Errorf("ref %s not found in %s", gitOrigin.Ref, gitOrigin.URL)
+		}
+
+		result.Current = current.Commit
+		result.Latest = latest
+		result.Outdated = current.Commit != latest
+		return result, nil
+	}
+
+	key := extensionConfig.Origin
+	validators := index.Validators(key)
+
+	req, err := http.NewRequest(http.MethodGet, extensionConfig.Origin, nil)
+	if err != nil {
+		return OutdatedResult{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// the validators only describe the installed version if it was downloaded, not restored by a rollback
+	if current.Sha256 != "" {
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return OutdatedResult{}, fmt.Errorf("failed to check extension: %w", err)
+	}
+	defer resp.Body.Close()
+
+	result.Current = current.Sha256
+	if resp.StatusCode == http.StatusNotModified {
+		result.Latest = current.Sha256
+		return result, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return OutdatedResult{}, fmt.Errorf("failed to check extension: %s", resp.Status)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, resp.Body); err != nil {
+		return OutdatedResult{}, fmt.Errorf("failed to check extension: %w", err)
+	}
+
+	result.Latest = hex.EncodeToString(hash.Sum(nil))
+	result.Outdated = result.Latest != result.Current
+
+	// the validators are only recorded when they match the installed version, otherwise the next check would miss the update
+	if !result.Outdated {
+		index.SetValidators(key, responseValidators(resp))
+	}
+
+	return result, nil
+}
+
+// remoteCommit returns the commit the ref of the origin points to, or an empty string if the ref is not advertised by the remote.
+// Branches take precedence over tags, as when cloning.
+func remoteCommit(origin GitOrigin) (string, error) {
+	ref := origin.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	output, err := git("", "ls-remote", origin.URL, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to check %s: %w", origin.URL, err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refs[fields[1]] = fields[0]
+	}
+
+	for _, name := range []string{"HEAD", "refs/heads/" + ref, "refs/tags/" + ref + "^{}", "refs/tags/" + ref} {
+		if commit, ok := refs[name]; ok {
+			return commit, nil
+		}
+	}
+
+	return "", nil
+}
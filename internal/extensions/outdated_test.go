@@ -0,0 +1,207 @@
+package extensions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+const (
+	scriptV1 = "#!/bin/sh\necho '{\"title\": \"v1\", \"commands\": [{\"name\": \"hi\", \"title\": \"Say Hi\", \"mode\": \"detail\"}]}'\n"
+	scriptV2 = "#!/bin/sh\necho '{\"title\": \"v2\", \"commands\": [{\"name\": \"hi\", \"title\": \"Say Hi\", \"mode\": \"detail\"}]}'\n"
+)
+
+// extensionServer serves an extension, answering conditional requests when it sends validators
+type extensionServer struct {
+	mu           sync.Mutex
+	body         string
+	etag         string
+	lastModified string
+	requests     []*http.Request
+	notModified  int
+}
+
+func (s *extensionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r)
+	if s.etag != "" {
+		w.Header().Set("ETag", s.etag)
+		if r.Header.Get("If-None-Match") == s.etag {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if s.lastModified != "" {
+		w.Header().Set("Last-Modified", s.lastModified)
+		if s.etag == "" && r.Header.Get("If-Modified-Since") == s.lastModified {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Write([]byte(s.body))
+}
+
+func (s *extensionServer) update(body, etag, lastModified string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.body = body
+	s.etag = etag
+	s.lastModified = lastModified
+}
+
+func (s *extensionServer) lastRequest() *http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[len(s.requests)-1]
+}
+
+// installRemote serves the extension, and downloads it as sunbeam would on the first load
+func installRemote(t *testing.T, server *extensionServer) config.Config {
+	t.Helper()
+
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	dir := isolateCache(t)
+	cfg := writeConfig(t, dir, map[string]config.ExtensionConfig{
+		"remote": {Origin: ts.URL + "/remote.sh"},
+	})
+
+	if _, errs := LoadExtensions(cfg); len(errs) > 0 {
+		t.Fatalf("failed to install extension: %v", errs)
+	}
+
+	return cfg
+}
+
+func checkOutdated(t *testing.T, cfg config.Config) OutdatedResult {
+	t.Helper()
+
+	index, err := LoadIndex(IndexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := CheckOutdated(cfg, "remote", index, lockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCheckOutdatedNotModified(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		etag         string
+		lastModified string
+	}{
+		{name: "etag", etag: `"v1"`},
+		{name: "last-modified", lastModified: "Mon, 05 Oct 2026 10:00:00 GMT"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &extensionServer{body: scriptV1, etag: tc.etag, lastModified: tc.lastModified}
+			cfg := installRemote(t, server)
+
+			res := checkOutdated(t, cfg)
+			if res.Outdated {
+				t.Errorf("expected the extension to be up to date")
+			}
+
+			if res.Current != checksum(scriptV1) || res.Latest != res.Current {
+				t.Errorf("expected current and latest to be %s, got %s and %s", checksum(scriptV1), res.Current, res.Latest)
+			}
+
+			if server.notModified != 1 {
+				t.Errorf("expected a 304 response, got %d", server.notModified)
+			}
+
+			req := server.lastRequest()
+			if tc.etag != "" && req.Header.Get("If-None-Match") != tc.etag {
+				t.Errorf("expected If-None-Match %s, got %q", tc.etag, req.Header.Get("If-None-Match"))
+			}
+			if tc.lastModified != "" && req.Header.Get("If-Modified-Since") != tc.lastModified {
+				t.Errorf("expected If-Modified-Since %s, got %q", tc.lastModified, req.Header.Get("If-Modified-Since"))
+			}
+		})
+	}
+}
+
+func TestCheckOutdatedChangedETag(t *testing.T) {
+	server := &extensionServer{body: scriptV1, etag: `"v1"`}
+	cfg := installRemote(t, server)
+
+	server.update(scriptV2, `"v2"`, "")
+
+	res := checkOutdated(t, cfg)
+	if !res.Outdated {
+		t.Fatalf("expected the extension to be outdated")
+	}
+
+	if res.Current != checksum(scriptV1) {
+		t.Errorf("expected current to be %s, got %s", checksum(scriptV1), res.Current)
+	}
+
+	if res.Latest != checksum(scriptV2) {
+		t.Errorf("expected latest to be %s, got %s", checksum(scriptV2), res.Latest)
+	}
+
+	// the validators of the new version must not be recorded before it is installed, or the next check would miss it
+	res = checkOutdated(t, cfg)
+	if !res.Outdated {
+		t.Errorf("expected the extension to still be outdated")
+	}
+
+	if req := server.lastRequest(); req.Header.Get("If-None-Match") != `"v1"` {
+		t.Errorf("expected If-None-Match \"v1\", got %q", req.Header.Get("If-None-Match"))
+	}
+}
+
+func TestCheckOutdatedNoValidators(t *testing.T) {
+	server := &extensionServer{body: scriptV1}
+	cfg := installRemote(t, server)
+
+	res := checkOutdated(t, cfg)
+	if res.Outdated {
+		t.Errorf("expected the extension to be up to date")
+	}
+
+	req := server.lastRequest()
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		t.Errorf("expected an unconditional request, got %v", req.Header)
+	}
+
+	server.update(scriptV2, "", "")
+
+	res = checkOutdated(t, cfg)
+	if !res.Outdated {
+		t.Fatalf("expected the extension to be outdated")
+	}
+
+	if res.Latest != checksum(scriptV2) {
+		t.Errorf("expected latest to be %s, got %s", checksum(scriptV2), res.Latest)
+	}
+}
@@ -0,0 +1,48 @@
+package extensions
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// PreferenceEnv returns the environment variable overriding a preference of an extension
+func PreferenceEnv(alias string, name string) string {
+	env := fmt.Sprintf("%s_%s", strings.ToUpper(alias), strings.ToUpper(name))
+	return strings.ReplaceAll(env, "-", "_")
+}
+
+// PreferencesFromEnv reads the preferences set in the environment, invalid booleans are ignored
+func PreferencesFromEnv(alias string, inputs []sunbeam.Input) (map[string]any, error) {
+	var preferences = make(map[string]any)
+	for _, input := range inputs {
+		value, ok := os.LookupEnv(PreferenceEnv(alias, input.Name))
+		if !ok {
+			continue
+		}
+
+		switch input.Type {
+		case sunbeam.InputString:
+			preferences[input.Name] = value
+		case sunbeam.InputBoolean:
+			value, err := strconv.ParseBool(value)
+			if err != nil {
+				continue
+			}
+
+			preferences[input.Name] = value
+		case sunbeam.InputNumber:
+			value, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+
+			preferences[input.Name] = value
+		}
+	}
+
+	return preferences, nil
+}
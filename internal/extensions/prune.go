@@ -0,0 +1,149 @@
+package extensions
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+)
+
+// PrunedDir is a cache directory that matches no known origin
+type PrunedDir struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type PruneResult struct {
+	Dirs         []PrunedDir `json:"dirs"`
+	IndexEntries []string    `json:"indexEntries"`
+}
+
+func (r PruneResult) Size() int64 {
+	var size int64
+	for _, dir := range r.Dirs {
+		size += dir.Size
+	}
+
+	return size
+}
+
+// FindPrunable lists the cache directories and the index entries that match none of the origins, without removing anything.
+// Local origins must be resolved, the same file may be declared with different relative paths.
+func FindPrunable(origins []string) (PruneResult, error) {
+	hashes := make(map[string]bool)
+	keys := make(map[string]bool)
+	for _, origin := range origins {
+		hash, err := Hash(origin)
+		if err != nil {
+			return PruneResult{}, err
+		}
+		hashes[hash] = true
+
+		if key, ok := pruneIndexKey(origin); ok {
+			keys[key] = true
+		}
+	}
+
+	var result PruneResult
+	// the staging area is pruned too, leftovers of interrupted upgrades would never be cleaned otherwise
+	for _, parent := range []string{filepath.Join(utils.CacheDir(), "extensions"), filepath.Join(utils.CacheDir(), "staging")} {
+		entries, err := os.ReadDir(parent)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return PruneResult{}, fmt.Errorf("failed to read cache directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || hashes[entry.Name()] {
+				continue
+			}
+
+			dir := filepath.Join(parent, entry.Name())
+			size, err := dirSize(dir)
+			if err != nil {
+				return PruneResult{}, err
+			}
+
+			result.Dirs = append(result.Dirs, PrunedDir{Path: dir, Size: size})
+		}
+	}
+
+	index, err := LoadIndex(IndexPath)
+	if err != nil {
+		return PruneResult{}, err
+	}
+
+	for _, key := range index.Keys() {
+		if !keys[key] {
+			result.IndexEntries = append(result.IndexEntries, key)
+		}
+	}
+
+	return result, nil
+}
+
+// Remove deletes the directories and the index entries of the result, and nothing else
+func (r PruneResult) Remove() error {
+	for _, dir := range r.Dirs {
+		if err := os.RemoveAll(dir.Path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dir.Path, err)
+		}
+	}
+
+	index, err := LoadIndex(IndexPath)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range r.IndexEntries {
+		index.Delete(key)
+	}
+
+	return index.Save()
+}
+
+// pruneIndexKey returns the key of an origin in the index, without downloading or unpacking anything
+func pruneIndexKey(origin string) (string, bool) {
+	if IsRemote(origin) {
+		return origin, true
+	}
+
+	extensionDir, err := ExtensionDir(origin)
+	if err != nil {
+		return "", false
+	}
+
+	entrypoint, err := ResolveEntrypoint(origin, extensionDir)
+	if err != nil {
+		return "", false
+	}
+
+	return entrypoint, true
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute the size of %s: %w", dir, err)
+	}
+
+	return size, nil
+}
@@ -0,0 +1,63 @@
+package extensions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/utils"
+)
+
+// cacheDir creates the cache directory of an origin, as a download would
+func cacheDir(t *testing.T, origin string) string {
+	t.Helper()
+
+	hash, err := Hash(origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(utils.CacheDir(), "extensions", hash)
+	if err := os.MkdirAll(filepath.Join(dir, "versions"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "entrypoint.sh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir
+}
+
+func TestFindPrunable(t *testing.T) {
+	isolateCache(t)
+
+	kept := cacheDir(t, "https://example.com/kept.sh")
+	other := cacheDir(t, "https://example.com/other.sh")
+
+	res, err := FindPrunable([]string{"https://example.com/kept.sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Dirs) != 1 || res.Dirs[0].Path != other {
+		t.Fatalf("expected only %s to be prunable, got %v", other, res.Dirs)
+	}
+
+	// listing must not remove anything
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("expected %s to be kept until the result is removed", other)
+	}
+
+	if err := res.Remove(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", other)
+	}
+
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("expected %s to be kept", kept)
+	}
+}
@@ -0,0 +1,94 @@
+package extensions
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// RequirementsError lists what prevents an extension from running on this machine
+type RequirementsError struct {
+	Alias string
+	// Platforms is only set when the current platform is not supported
+	Platforms []sunbeam.Platform
+	Missing   []sunbeam.Requirement
+}
+
+func (e RequirementsError) Error() string {
+	var lines []string
+	if len(e.Platforms) > 0 {
+		platforms := make([]string, 0, len(e.Platforms))
+		for _, platform := range e.Platforms {
+			platforms = append(platforms, string(platform))
+		}
+
+		lines = append(lines, fmt.Sprintf("extension %s does not support %s (supported platforms: %s)", e.Alias, CurrentPlatform(), strings.Join(platforms, ", ")))
+	}
+
+	if len(e.Missing) > 0 {
+		lines = append(lines, fmt.Sprintf("extension %s is missing requirements:", e.Alias))
+		for _, requirement := range e.Missing {
+			if requirement.Link != "" {
+				lines = append(lines, fmt.Sprintf("  - %s, see %s", requirement.Name, requirement.Link))
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("  - %s", requirement.Name))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// UnsupportedPlatform reports whether the extension cannot run on this platform at all, missing requirements can still be installed
+func (e RequirementsError) UnsupportedPlatform() bool {
+	return len(e.Platforms) > 0
+}
+
+// CurrentPlatform returns the platform sunbeam is running on, using the names of the manifest
+func CurrentPlatform() sunbeam.Platform {
+	switch runtime.GOOS {
+	case "darwin":
+		return sunbeam.PlatformMac
+	default:
+		return sunbeam.Platform(runtime.GOOS)
+	}
+}
+
+// CheckRequirements returns a RequirementsError if the platform is not supported, or if a required executable is missing from the PATH
+func CheckRequirements(alias string, manifest sunbeam.Manifest) error {
+	var requirementsErr RequirementsError
+	requirementsErr.Alias = alias
+
+	if len(manifest.Platforms) > 0 {
+		supported := false
+		for _, platform := range manifest.Platforms {
+			if platform == CurrentPlatform() {
+				supported = true
+				break
+			}
+		}
+
+		if !supported {
+			requirementsErr.Platforms = manifest.Platforms
+		}
+	}
+
+	for _, requirement := range manifest.Requirements {
+		if _, err := exec.LookPath(requirement.Name); err != nil {
+			requirementsErr.Missing = append(requirementsErr.Missing, requirement)
+		}
+	}
+
+	if len(requirementsErr.Platforms) == 0 && len(requirementsErr.Missing) == 0 {
+		return nil
+	}
+
+	return requirementsErr
+}
+
+func (e Extension) CheckRequirements() error {
+	return CheckRequirements(e.Alias, e.Manifest)
+}
@@ -0,0 +1,32 @@
+#!/bin/sh
+
+if [ $# -eq 0 ]; then
+  cat <<'MANIFEST'
+{
+  "title": "Test Suite",
+  "commands": [
+    {"name": "greet", "title": "Greet", "mode": "detail"},
+    {"name": "repos", "title": "Search Repositories", "mode": "filter"},
+    {"name": "broken", "title": "Broken", "mode": "search"},
+    {"name": "shell", "title": "Shell", "mode": "tty"}
+  ]
+}
+MANIFEST
+  exit 0
+fi
+
+case "$1" in
+  *'"command":"greet"'*)
+    echo '{"markdown": "Hello", "actions": [{"title": "Copy", "type": "copy", "text": "Hello"}]}'
+    ;;
+  *'"command":"repos"'*)
+    echo '{"items": [
+      {"title": "sunbeam", "subtitle": "pomdtr", "actions": [{"title": "Open", "type": "open", "url": "https://github.com/pomdtr/sunbeam"}]},
+      {"title": "smallweb", "subtitle": "pomdtr"},
+      {"title": "fzf", "subtitle": "junegunn"}
+    ]}'
+    ;;
+  *'"command":"broken"'*)
+    echo '{"items": [{"subtitle": "missing title"}]}'
+    ;;
+esac
@@ -0,0 +1,213 @@
+package extensions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/fzf"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// TestSuffix is the suffix of the file holding the test cases of an entrypoint
+const TestSuffix = ".test.json"
+
+type TestSuite struct {
+	Cases []TestCase `json:"cases"`
+}
+
+// TestCase is a run of a command, and the expectations on its output
+type TestCase struct {
+	Name        string          `json:"name"`
+	Command     string          `json:"command"`
+	Params      map[string]any  `json:"params,omitempty"`
+	Preferences map[string]any  `json:"preferences,omitempty"`
+	Query       string          `json:"query,omitempty"`
+	Expect      TestExpectation `json:"expect,omitempty"`
+}
+
+type TestExpectation struct {
+	// Invalid expects the output to fail the schema validation, outputs are expected to be valid otherwise
+	Invalid   bool `json:"invalid,omitempty"`
+	ItemCount *int `json:"itemCount,omitempty"`
+	// Titles are regular expressions matched against the titles of the first items, in order
+	Titles []string `json:"titles,omitempty"`
+	// Actions are the titles of the actions expected on the detail, or on the first item of the list
+	Actions []string `json:"actions,omitempty"`
+}
+
+type TestResult struct {
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Duration time.Duration `json:"duration"`
+	Failures []string      `json:"failures,omitempty"`
+}
+
+func LoadTestSuite(path string) (TestSuite, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return TestSuite{}, fmt.Errorf("failed to read test suite: %w", err)
+	}
+
+	var suite TestSuite
+	if err := json.Unmarshal(bs, &suite); err != nil {
+		return TestSuite{}, fmt.Errorf("failed to parse test suite %s: %w", path, err)
+	}
+
+	for i, testCase := range suite.Cases {
+		if testCase.Command == "" {
+			return TestSuite{}, fmt.Errorf("test case %d has no command", i+1)
+		}
+
+		if testCase.Name == "" {
+			suite.Cases[i].Name = fmt.Sprintf("%s #%d", testCase.Command, i+1)
+		}
+	}
+
+	return suite, nil
+}
+
+// RunTest runs the command of a test case, and checks its output against the expectations.
+// Filter lists are filtered by the query, as they are in the launcher.
+func (e Extension) RunTest(ctx context.Context, testCase TestCase) TestResult {
+	startedAt := time.Now()
+	result := TestResult{Name: testCase.Name}
+	result.Failures = e.runTest(ctx, testCase)
+	result.Passed = len(result.Failures) == 0
+	result.Duration = time.Since(startedAt)
+
+	return result
+}
+
+func (e Extension) runTest(ctx context.Context, testCase TestCase) []string {
+	command, ok := e.Command(testCase.Command)
+	if !ok {
+		return []string{fmt.Sprintf("command %s not found", testCase.Command)}
+	}
+
+	if command.Mode == sunbeam.CommandModeTTY {
+		return []string{fmt.Sprintf("command %s runs in tty mode, it cannot be tested", command.Name)}
+	}
+
+	output, err := e.OutputContext(ctx, sunbeam.Payload{
+		Command:     testCase.Command,
+		Params:      testCase.Params,
+		Preferences: testCase.Preferences,
+		Query:       testCase.Query,
+	})
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	schema, validate := outputSchema(command.Mode)
+	if validate == nil {
+		return nil
+	}
+
+	if err := validate(output); err != nil {
+		if testCase.Expect.Invalid {
+			return nil
+		}
+
+		return []string{fmt.Sprintf("output is not a valid %s: %s", schema, err)}
+	} else if testCase.Expect.Invalid {
+		return []string{fmt.Sprintf("output is a valid %s", schema)}
+	}
+
+	var titles []string
+	var actions []sunbeam.Action
+	switch command.Mode {
+	case sunbeam.CommandModeDetail:
+		var detail sunbeam.Detail
+		if err := json.Unmarshal(output, &detail); err != nil {
+			return []string{err.Error()}
+		}
+
+		actions = detail.Actions
+	default:
+		var list sunbeam.List
+		if err := json.Unmarshal(output, &list); err != nil {
+			return []string{err.Error()}
+		}
+
+		items := list.Items
+		if command.Mode == sunbeam.CommandModeFilter {
+			items = fzf.Filter(items, testCase.Query, sunbeam.ListItem.FilterValue)
+		}
+
+		for _, item := range items {
+			titles = append(titles, item.Title)
+		}
+
+		if len(items) > 0 {
+			actions = items[0].Actions
+		} else {
+			actions = list.Actions
+		}
+	}
+
+	return checkExpectation(testCase.Expect, command.Mode, titles, actions)
+}
+
+func checkExpectation(expect TestExpectation, mode sunbeam.CommandMode, titles []string, actions []sunbeam.Action) []string {
+	var failures []string
+	if mode != sunbeam.CommandModeDetail {
+		if expect.ItemCount != nil && len(titles) != *expect.ItemCount {
+			failures = append(failures, fmt.Sprintf("expected %d items, got %d", *expect.ItemCount, len(titles)))
+		}
+
+		if diff, ok := diffTitles(expect.Titles, titles); !ok {
+			failures = append(failures, "titles do not match:\n"+diff)
+		}
+	} else if expect.ItemCount != nil || len(expect.Titles) > 0 {
+		failures = append(failures, "items are only expected from list commands")
+	}
+
+	present := make(map[string]bool)
+	for _, action := range actions {
+		present[action.Title] = true
+	}
+
+	for _, title := range expect.Actions {
+		if !present[title] {
+			failures = append(failures, fmt.Sprintf("action %q is missing", title))
+		}
+	}
+
+	return failures
+}
+
+// diffTitles compares the expected patterns with the titles line by line.
+// Matching lines are prefixed with spaces, mismatches with - for the pattern and + for the title.
+func diffTitles(patterns []string, titles []string) (string, bool) {
+	var diff strings.Builder
+	ok := true
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fmt.Fprintf(&diff, "- %s (invalid pattern: %s)\n", pattern, err)
+			ok = false
+			continue
+		}
+
+		if i >= len(titles) {
+			fmt.Fprintf(&diff, "- %s\n", pattern)
+			ok = false
+			continue
+		}
+
+		if re.MatchString(titles[i]) {
+			fmt.Fprintf(&diff, "  %s\n", titles[i])
+			continue
+		}
+
+		fmt.Fprintf(&diff, "- %s\n+ %s\n", pattern, titles[i])
+		ok = false
+	}
+
+	return strings.TrimSuffix(diff.String(), "\n"), ok
+}
@@ -0,0 +1,90 @@
+package extensions
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+)
+
+func TestRunTestSuite(t *testing.T) {
+	dir := isolateCache(t)
+	entrypoint, err := filepath.Abs(filepath.Join("testdata", "testsuite", "extension.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := writeConfig(t, dir, map[string]config.ExtensionConfig{
+		"suite": {Origin: entrypoint},
+	})
+
+	extension, err := LoadExtension(cfg, "suite")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suite, err := LoadTestSuite(entrypoint + TestSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the failures expected from each case, an empty list means the case passes
+	expected := map[string][]string{
+		"greet":                {},
+		"greet missing action": {`action "Open" is missing`},
+		"greet items":          {"items are only expected from list commands"},
+		"repos":                {},
+		"repos filtered":       {},
+		"repos ranked":         {},
+		"repos wrong titles":   {"expected 2 items, got 3", "titles do not match:\n  sunbeam\n- fzf\n+ smallweb"},
+		"broken":               {},
+		"broken unexpected":    {"output is not a valid list"},
+		"shell":                {"command shell runs in tty mode, it cannot be tested"},
+		"missing #11":          {"command missing not found"},
+	}
+
+	if len(suite.Cases) != len(expected) {
+		t.Fatalf("expected %d cases, got %d", len(expected), len(suite.Cases))
+	}
+
+	for _, testCase := range suite.Cases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			failures, ok := expected[testCase.Name]
+			if !ok {
+				t.Fatalf("unexpected case %s", testCase.Name)
+			}
+
+			result := extension.RunTest(context.Background(), testCase)
+			if result.Name != testCase.Name {
+				t.Errorf("expected the result to be named %s, got %s", testCase.Name, result.Name)
+			}
+
+			if result.Passed != (len(failures) == 0) {
+				t.Fatalf("expected passed to be %t, got failures %q", len(failures) == 0, result.Failures)
+			}
+
+			if len(result.Failures) != len(failures) {
+				t.Fatalf("expected failures %q, got %q", failures, result.Failures)
+			}
+
+			for i, failure := range failures {
+				if !strings.HasPrefix(result.Failures[i], failure) {
+					t.Errorf("expected failure %q, got %q", failure, result.Failures[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadTestSuiteInvalid(t *testing.T) {
+	if _, err := LoadTestSuite(filepath.Join("testdata", "testsuite", "missing.test.json")); err == nil {
+		t.Errorf("expected a missing suite to be rejected")
+	}
+
+	// the script is not json
+	if _, err := LoadTestSuite(filepath.Join("testdata", "testsuite", "extension.sh")); err == nil {
+		t.Errorf("expected an invalid suite to be rejected")
+	}
+}
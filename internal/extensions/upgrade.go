@@ -0,0 +1,453 @@
+package extensions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+type UpgradeResult struct {
+	OldSha256 string
+	NewSha256 string
+	OldCommit string
+	NewCommit string
+}
+
+// StagedUpgrade is a new version of a remote extension, downloaded to a staging area.
+// Git extensions are cloned again, following the ref of the origin.
+// Nothing is modified until Apply is called.
+type StagedUpgrade struct {
+	Alias      string
+	Origin     string
+	Entrypoint string
+	Staged     string
+
+	OldSha256   string
+	NewSha256   string
+	OldCommit   string
+	NewCommit   string
+	OldManifest sunbeam.Manifest
+	NewManifest sunbeam.Manifest
+	// ManifestAvailable is false when the staged entrypoint has no static manifest.
+	// Its manifest is then extracted once the upgrade is applied, since the staged entrypoint must not run before it is approved.
+	ManifestAvailable bool
+
+	extension    Extension
+	lockPath     string
+	indexPath    string
+	keepVersions int
+	stagingDir   string
+	repoDir      string
+	bundleDir    string
+	archive      string
+	validators   CacheValidators
+}
+
+type ManifestChanges struct {
+	AddedCommands      []sunbeam.CommandSpec
+	RemovedCommands    []sunbeam.CommandSpec
+	AddedPreferences   []sunbeam.Input
+	RemovedPreferences []sunbeam.Input
+}
+
+func (c ManifestChanges) IsEmpty() bool {
+	return len(c.AddedCommands) == 0 && len(c.RemovedCommands) == 0 && len(c.AddedPreferences) == 0 && len(c.RemovedPreferences) == 0
+}
+
+func DiffManifests(old sunbeam.Manifest, new sunbeam.Manifest) ManifestChanges {
+	var changes ManifestChanges
+
+	oldCommands := make(map[string]bool)
+	for _, command := range old.Commands {
+		oldCommands[command.Name] = true
+	}
+	newCommands := make(map[string]bool)
+	for _, command := range new.Commands {
+		newCommands[command.Name] = true
+		if !oldCommands[command.Name] {
+			changes.AddedCommands = append(changes.AddedCommands, command)
+		}
+	}
+	for _, command := range old.Commands {
+		if !newCommands[command.Name] {
+			changes.RemovedCommands = append(changes.RemovedCommands, command)
+		}
+	}
+
+	oldPreferences := make(map[string]bool)
+	for _, preference := range old.Preferences {
+		oldPreferences[preference.Name] = true
+	}
+	newPreferences := make(map[string]bool)
+	for _, preference := range new.Preferences {
+		newPreferences[preference.Name] = true
+		if !oldPreferences[preference.Name] {
+			changes.AddedPreferences = append(changes.AddedPreferences, preference)
+		}
+	}
+	for _, preference := range old.Preferences {
+		if !newPreferences[preference.Name] {
+			changes.RemovedPreferences = append(changes.RemovedPreferences, preference)
+		}
+	}
+
+	return changes
+}
+
+// StageUpgrade downloads the latest version of a remote extension to the staging area, and reads its static manifest.
+// The staged entrypoint is never executed.
+func StageUpgrade(cfg config.Config, alias string) (*StagedUpgrade, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return nil, fmt.Errorf("extension %s not found", alias)
+	}
+
+	if !IsRemote(extensionConfig.Origin) {
+		return nil, fmt.Errorf("extension %s is not a remote extension", alias)
+	}
+
+	hash, err := Hash(extensionConfig.Origin)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := LoadIndex(indexPath(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	extensionDir := filepath.Join(utils.CacheDir(), "extensions", hash)
+	entrypoint, err := ResolveEntrypoint(extensionConfig.Origin, extensionDir)
+	if err != nil && !IsBundle(extensionConfig.Origin) {
+		return nil, err
+	}
+
+	stagingDir := filepath.Join(utils.CacheDir(), "staging", hash)
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	upgrade := &StagedUpgrade{
+		Alias:      alias,
+		Origin:     extensionConfig.Origin,
+		Entrypoint: entrypoint,
+		Staged:     filepath.Join(stagingDir, filepath.Base(entrypoint)),
+		extension: Extension{
+			Alias:    alias,
+			Timeouts: NewTimeouts(cfg.ExtensionTimeouts(alias)),
+			Env:      NewEnvPolicy(extensionConfig),
+		},
+		lockPath:     LockPath(cfg),
+		indexPath:    indexPath(cfg),
+		keepVersions: keepVersions(cfg),
+		stagingDir:   stagingDir,
+	}
+
+	if entry, ok := lockfile.Get(extensionConfig.Origin); ok {
+		upgrade.OldSha256 = entry.Sha256
+		upgrade.OldCommit = entry.Commit
+	} else if err := lockfile.CheckPinned(extensionConfig.Origin); err != nil {
+		return nil, err
+	} else if file, err := archivedFile(extensionConfig.Origin, extensionDir); err == nil {
+		upgrade.OldSha256, _ = Sha256(file)
+	}
+
+	if manifest, ok := CachedManifest(index, extensionConfig.Origin); ok {
+		upgrade.OldManifest = manifest
+	}
+
+	if IsGit(extensionConfig.Origin) {
+		gitOrigin, err := ParseGitOrigin(extensionConfig.Origin)
+		if err != nil {
+			_ = upgrade.Discard()
+			return nil, err
+		}
+
+		upgrade.repoDir = filepath.Join(extensionDir, "repo")
+		upgrade.Staged = filepath.Join(stagingDir, "repo", gitOrigin.Path)
+		upgrade.NewCommit, err = CloneRepository(gitOrigin, filepath.Join(stagingDir, "repo"), "")
+		if err != nil {
+			_ = upgrade.Discard()
+			return nil, err
+		}
+
+		if _, err := os.Stat(upgrade.Staged); err != nil {
+			_ = upgrade.Discard()
+			return nil, fmt.Errorf("entrypoint %s not found in %s", gitOrigin.Path, gitOrigin.URL)
+		}
+	} else if IsBundle(extensionConfig.Origin) {
+		upgrade.bundleDir = filepath.Join(extensionDir, "bundle")
+		upgrade.archive, err = bundleArchive(extensionConfig.Origin, extensionDir)
+		if err != nil {
+			_ = upgrade.Discard()
+			return nil, err
+		}
+
+		archive := filepath.Join(stagingDir, filepath.Base(upgrade.archive))
+		upgrade.validators, err = DownloadEntrypoint(extensionConfig.Origin, archive)
+		if err != nil {
+			_ = upgrade.Discard()
+			return nil, err
+		}
+
+		upgrade.NewSha256, err = Sha256(archive)
+		if err != nil {
+			_ = upgrade.Discard()
+			return nil, err
+		}
+
+		upgrade.Staged, err = UnpackBundle(archive, filepath.Join(stagingDir, "bundle"))
+		if err != nil {
+			_ = upgrade.Discard()
+			return nil, err
+		}
+	} else if upgrade.validators, err = DownloadEntrypoint(extensionConfig.Origin, upgrade.Staged); err != nil {
+		_ = upgrade.Discard()
+		return nil, err
+	}
+
+	if upgrade.NewSha256 == "" {
+		upgrade.NewSha256, err = Sha256(upgrade.Staged)
+		if err != nil {
+			_ = upgrade.Discard()
+			return nil, err
+		}
+	}
+
+	if Frozen && upgrade.NewCommit != upgrade.OldCommit {
+		_ = upgrade.Discard()
+		return nil, IntegrityError{Origin: extensionConfig.Origin, Kind: "commit", Expected: upgrade.OldCommit, Actual: upgrade.NewCommit}
+	}
+
+	if Frozen && upgrade.NewSha256 != upgrade.OldSha256 {
+		_ = upgrade.Discard()
+		return nil, IntegrityError{Origin: extensionConfig.Origin, Kind: "sha256", Expected: upgrade.OldSha256, Actual: upgrade.NewSha256}
+	}
+
+	upgrade.NewManifest, upgrade.ManifestAvailable, err = ReadStaticManifest(upgrade.Staged)
+	if err != nil {
+		_ = upgrade.Discard()
+		return nil, err
+	}
+
+	return upgrade, nil
+}
+
+func (u *StagedUpgrade) IsUpToDate() bool {
+	return u.OldSha256 == u.NewSha256 && u.OldCommit == u.NewCommit
+}
+
+// Diff returns a unified diff between the current entrypoint and the staged one.
+// For git extensions, the diff covers the whole repository when the previous commit is known, and every file of the bundle for bundles.
+func (u *StagedUpgrade) Diff() (string, error) {
+	if u.bundleDir != "" {
+		return diffBundles(u.bundleDir, filepath.Join(u.stagingDir, "bundle"))
+	}
+
+	if u.OldCommit != "" && u.NewCommit != "" {
+		if diff, err := GitDiff(filepath.Join(u.stagingDir, "repo"), u.OldCommit, u.NewCommit); err == nil {
+			return diff, nil
+		}
+	}
+
+	oldBytes, err := os.ReadFile(u.Entrypoint)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	newBytes, err := os.ReadFile(u.Staged)
+	if err != nil {
+		return "", err
+	}
+
+	name := filepath.Base(u.Entrypoint)
+	return udiff.Unified("a/"+name, "b/"+name, string(oldBytes), string(newBytes)), nil
+}
+
+// ManifestChanges compares the manifests of the current and staged versions, it returns false if the staged manifest is not available yet
+func (u *StagedUpgrade) ManifestChanges() (ManifestChanges, bool) {
+	if !u.ManifestAvailable {
+		return ManifestChanges{}, false
+	}
+
+	return DiffManifests(u.OldManifest, u.NewManifest), true
+}
+
+// Apply swaps the staged entrypoint in, and records its checksum and commit in the lockfile.
+// Staged entrypoints without a static manifest are run to extract it, so Apply must only be called once the upgrade is approved.
+// The index and the lockfile are loaded again, so that upgrades staged concurrently can be applied one after the other.
+func (u *StagedUpgrade) Apply() (UpgradeResult, error) {
+	defer u.Discard()
+
+	if !u.ManifestAvailable {
+		staged := u.extension
+		staged.Entrypoint = u.Staged
+
+		manifest, err := staged.ExtractManifest()
+		if err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to extract manifest: %w", err)
+		}
+
+		u.NewManifest = manifest
+		u.ManifestAvailable = true
+	}
+
+	index, err := LoadIndex(u.indexPath)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	lockfile, err := LoadLockfile(u.lockPath)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	if u.repoDir != "" {
+		// the entrypoint is nested in the repository, so the whole clone is swapped
+		if err := os.MkdirAll(filepath.Dir(u.repoDir), 0755); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		if err := os.RemoveAll(u.repoDir); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to remove repository: %w", err)
+		}
+
+		if err := os.Rename(filepath.Join(u.stagingDir, "repo"), u.repoDir); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to replace repository: %w", err)
+		}
+	} else if u.bundleDir != "" {
+		if err := os.MkdirAll(filepath.Dir(u.bundleDir), 0755); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		if err := os.Rename(filepath.Join(u.stagingDir, filepath.Base(u.archive)), u.archive); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to replace bundle: %w", err)
+		}
+
+		if err := os.RemoveAll(u.bundleDir); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to remove bundle: %w", err)
+		}
+
+		if err := os.Rename(filepath.Join(u.stagingDir, "bundle"), u.bundleDir); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to replace bundle: %w", err)
+		}
+
+		// the descriptor of the new version may point to another entrypoint
+		entrypoint, err := resolveBundleEntrypoint(u.bundleDir)
+		if err != nil {
+			return UpgradeResult{}, err
+		}
+		u.Entrypoint = entrypoint
+	} else {
+		if err := os.MkdirAll(filepath.Dir(u.Entrypoint), 0755); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to create directory: %w", err)
+		}
+
+		// downloads are not executable, and the manifest extraction which would fix it is skipped for static manifests
+		if err := os.Chmod(u.Staged, 0755); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to chmod entrypoint: %w", err)
+		}
+
+		if err := os.Rename(u.Staged, u.Entrypoint); err != nil {
+			return UpgradeResult{}, fmt.Errorf("failed to replace entrypoint: %w", err)
+		}
+	}
+
+	modTime, err := manifestModTime(u.Entrypoint)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	entry := LockEntry{Sha256: u.NewSha256, Commit: u.NewCommit}
+	if err := archiveVersion(u.Origin, u.Entrypoint, entry, u.NewManifest, u.keepVersions); err != nil {
+		return UpgradeResult{}, err
+	}
+
+	index.Set(indexKey(u.Origin, u.Entrypoint), IndexEntry{
+		Entrypoint:      u.Entrypoint,
+		ModTime:         modTime,
+		Manifest:        u.NewManifest,
+		CacheValidators: u.validators,
+	})
+	if err := index.Save(); err != nil {
+		return UpgradeResult{}, err
+	}
+
+	lockfile.Set(u.Origin, entry)
+	if err := lockfile.Save(); err != nil {
+		return UpgradeResult{}, err
+	}
+
+	return UpgradeResult{
+		OldSha256: u.OldSha256,
+		NewSha256: u.NewSha256,
+		OldCommit: u.OldCommit,
+		NewCommit: u.NewCommit,
+	}, nil
+}
+
+// Discard removes the staging area of the upgrade
+func (u *StagedUpgrade) Discard() error {
+	return os.RemoveAll(u.stagingDir)
+}
+
+// Upgrade upgrades a remote extension without confirmation, or refreshes the manifest of a local one
+func Upgrade(cfg config.Config, alias string) (UpgradeResult, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return UpgradeResult{}, fmt.Errorf("extension %s not found", alias)
+	}
+
+	if IsRemote(extensionConfig.Origin) {
+		upgrade, err := StageUpgrade(cfg, alias)
+		if err != nil {
+			return UpgradeResult{}, err
+		}
+
+		return upgrade.Apply()
+	}
+
+	index, err := LoadIndex(indexPath(cfg))
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	extensionDir, err := ExtensionDir(extensionConfig.Origin)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	// local bundles are unpacked again if their archive was modified
+	entrypoint, err := LoadEntrypoint(extensionConfig.Origin, extensionDir, lockfile)
+	if err != nil {
+		return UpgradeResult{}, err
+	}
+
+	extension := Extension{
+		Alias:      alias,
+		Entrypoint: entrypoint,
+		Timeouts:   NewTimeouts(cfg.ExtensionTimeouts(alias)),
+		Env:        NewEnvPolicy(extensionConfig),
+	}
+
+	if _, err := extension.indexManifest(index, indexKey(extensionConfig.Origin, entrypoint)); err != nil {
+		return UpgradeResult{}, err
+	}
+
+	return UpgradeResult{}, index.Save()
+}
@@ -0,0 +1,94 @@
+package extensions
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const (
+	staticScriptV1 = `#!/bin/sh
+# sunbeam:manifest
+# {"title": "v1", "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}]}
+# sunbeam:end
+echo '{"text": "v1"}'
+`
+	staticScriptV2 = `#!/bin/sh
+# sunbeam:manifest
+# {"title": "v2", "commands": [{"name": "hi", "title": "Say Hi", "mode": "detail"}, {"name": "bye", "title": "Say Bye", "mode": "detail"}]}
+# sunbeam:end
+echo '{"text": "v2"}'
+`
+)
+
+func TestUpgradeStaticManifest(t *testing.T) {
+	server := &extensionServer{body: staticScriptV1}
+	cfg := installRemote(t, server)
+
+	server.update(staticScriptV2, "", "")
+	upgrade, err := StageUpgrade(cfg, "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, ok := upgrade.ManifestChanges()
+	if !ok {
+		t.Fatalf("expected the static manifest to be compared before the upgrade is applied")
+	}
+
+	if len(changes.AddedCommands) != 1 || changes.AddedCommands[0].Name != "bye" {
+		t.Errorf("expected the bye command to be added, got %v", changes.AddedCommands)
+	}
+
+	if _, err := upgrade.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(upgrade.Entrypoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0755 {
+		t.Fatalf("expected the upgraded entrypoint to be executable, got %s", info.Mode())
+	}
+
+	extension, err := LoadExtension(cfg, "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := extension.Output(sunbeam.Payload{Command: "hi"})
+	if err != nil {
+		t.Fatalf("failed to run the upgraded extension: %v", err)
+	}
+
+	if !strings.Contains(string(output), "v2") {
+		t.Errorf("expected the output of v2, got %s", output)
+	}
+}
+
+func TestUpgradeDynamicManifest(t *testing.T) {
+	server := &extensionServer{body: scriptV1}
+	cfg := installRemote(t, server)
+
+	server.update(scriptV2, "", "")
+	upgrade, err := StageUpgrade(cfg, "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := upgrade.ManifestChanges(); ok {
+		t.Fatalf("expected the manifest not to be compared before the staged entrypoint runs")
+	}
+
+	if _, err := upgrade.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := upgrade.ManifestChanges(); !ok || upgrade.NewManifest.Title != "v2" {
+		t.Errorf("expected the manifest to be compared once the upgrade is applied, got %s", upgrade.NewManifest.Title)
+	}
+}
@@ -0,0 +1,312 @@
+package extensions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// DefaultKeepVersions is the number of versions kept for each remote extension, unless configured
+const DefaultKeepVersions = 5
+
+// keepVersions returns the number of versions kept for each remote extension
+func keepVersions(cfg config.Config) int {
+	if cfg.KeepVersions > 0 {
+		return cfg.KeepVersions
+	}
+
+	return DefaultKeepVersions
+}
+
+// Version is a previously downloaded entrypoint of a remote extension, or the archive of a bundle.
+// Only the commit is recorded for git extensions, since it is still available in the clone.
+type Version struct {
+	ID           int              `json:"id"`
+	Sha256       string           `json:"sha256"`
+	Commit       string           `json:"commit,omitempty"`
+	DownloadedAt time.Time        `json:"downloadedAt"`
+	Manifest     sunbeam.Manifest `json:"manifest"`
+	File         string           `json:"-"`
+
+	dir string
+}
+
+func versionsDir(origin string) (string, error) {
+	extensionDir, err := ExtensionDir(origin)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(extensionDir, "versions"), nil
+}
+
+// ListVersions returns the versions of a remote extension, newest first
+func ListVersions(origin string) ([]Version, error) {
+	dir, err := versionsDir(origin)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	versions := make([]Version, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		bts, err := os.ReadFile(filepath.Join(dir, entry.Name(), "version.json"))
+		if err != nil {
+			continue
+		}
+
+		var version Version
+		if err := json.Unmarshal(bts, &version); err != nil {
+			continue
+		}
+
+		version.dir = filepath.Join(dir, entry.Name())
+		if !IsGit(origin) {
+			file, err := archivedFile(origin, version.dir)
+			if err != nil {
+				return nil, err
+			}
+			version.File = file
+		}
+
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+
+	return versions, nil
+}
+
+// archivedFile returns the file kept for each version: the archive of bundles, the entrypoint otherwise
+func archivedFile(origin string, dir string) (string, error) {
+	if IsBundle(origin) {
+		return bundleArchive(origin, dir)
+	}
+
+	return ResolveEntrypoint(origin, dir)
+}
+
+// archiveVersion keeps a copy of the entrypoint, and prunes the versions beyond the keep most recent ones.
+// Nothing is archived if the latest version has the same checksum and commit.
+func archiveVersion(origin string, entrypoint string, entry LockEntry, manifest sunbeam.Manifest, keep int) error {
+	versions, err := ListVersions(origin)
+	if err != nil {
+		return err
+	}
+
+	nextID := 1
+	if len(versions) > 0 {
+		if versions[0].Sha256 == entry.Sha256 && versions[0].Commit == entry.Commit {
+			return nil
+		}
+		nextID = versions[0].ID + 1
+	}
+
+	dir, err := versionsDir(origin)
+	if err != nil {
+		return err
+	}
+
+	versionDir := filepath.Join(dir, strconv.Itoa(nextID))
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		return fmt.Errorf("failed to create version directory: %w", err)
+	}
+
+	if !IsGit(origin) {
+		extensionDir, err := ExtensionDir(origin)
+		if err != nil {
+			return err
+		}
+
+		file := entrypoint
+		if IsBundle(origin) {
+			file, err = bundleArchive(origin, extensionDir)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := copyFile(file, filepath.Join(versionDir, filepath.Base(file))); err != nil {
+			return fmt.Errorf("failed to archive entrypoint: %w", err)
+		}
+	}
+
+	bts, err := json.MarshalIndent(Version{
+		ID:           nextID,
+		Sha256:       entry.Sha256,
+		Commit:       entry.Commit,
+		DownloadedAt: time.Now(),
+		Manifest:     manifest,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(versionDir, "version.json"), bts, 0644); err != nil {
+		return fmt.Errorf("failed to write version: %w", err)
+	}
+
+	for i := max(keep-1, 0); i < len(versions); i++ {
+		if err := os.RemoveAll(versions[i].dir); err != nil {
+			return fmt.Errorf("failed to prune version: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Rollback restores a previous version of a remote extension.
+// If id is zero, the most recent version that differs from the current entrypoint is restored.
+func Rollback(cfg config.Config, alias string, id int) (Version, error) {
+	extensionConfig, ok := cfg.Extensions[alias]
+	if !ok {
+		return Version{}, fmt.Errorf("extension %s not found", alias)
+	}
+
+	if !IsRemote(extensionConfig.Origin) {
+		return Version{}, fmt.Errorf("extension %s is not a remote extension", alias)
+	}
+
+	extensionDir, err := ExtensionDir(extensionConfig.Origin)
+	if err != nil {
+		return Version{}, err
+	}
+
+	entrypoint, err := ResolveEntrypoint(extensionConfig.Origin, extensionDir)
+	if err != nil {
+		return Version{}, err
+	}
+
+	versions, err := ListVersions(extensionConfig.Origin)
+	if err != nil {
+		return Version{}, err
+	}
+
+	var current LockEntry
+	if file, err := archivedFile(extensionConfig.Origin, extensionDir); err == nil {
+		current.Sha256, _ = Sha256(file)
+	}
+	if IsGit(extensionConfig.Origin) {
+		current.Commit, _ = repositoryHead(filepath.Join(extensionDir, "repo"))
+	}
+
+	var target *Version
+	for i, version := range versions {
+		if id == 0 && (version.Sha256 != current.Sha256 || version.Commit != current.Commit) {
+			target = &versions[i]
+			break
+		}
+
+		if id != 0 && version.ID == id {
+			target = &versions[i]
+			break
+		}
+	}
+
+	if target == nil {
+		if id == 0 {
+			return Version{}, fmt.Errorf("no previous version of %s is available", alias)
+		}
+
+		return Version{}, fmt.Errorf("version %d of %s not found", id, alias)
+	}
+
+	index, err := LoadIndex(indexPath(cfg))
+	if err != nil {
+		return Version{}, err
+	}
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		return Version{}, err
+	}
+
+	if IsGit(extensionConfig.Origin) {
+		if err := CheckoutCommit(filepath.Join(extensionDir, "repo"), target.Commit); err != nil {
+			return Version{}, err
+		}
+	} else {
+		file, err := archivedFile(extensionConfig.Origin, extensionDir)
+		if err != nil {
+			return Version{}, err
+		}
+
+		tmp := file + ".rollback"
+		if err := copyFile(target.File, tmp); err != nil {
+			return Version{}, fmt.Errorf("failed to restore entrypoint: %w", err)
+		}
+
+		if err := os.Rename(tmp, file); err != nil {
+			os.Remove(tmp)
+			return Version{}, fmt.Errorf("failed to restore entrypoint: %w", err)
+		}
+
+		if IsBundle(extensionConfig.Origin) {
+			entrypoint, err = UnpackBundle(file, filepath.Join(extensionDir, "bundle"))
+			if err != nil {
+				return Version{}, err
+			}
+		}
+	}
+
+	modTime, err := manifestModTime(entrypoint)
+	if err != nil {
+		return Version{}, err
+	}
+
+	index.Set(indexKey(extensionConfig.Origin, entrypoint), IndexEntry{
+		Entrypoint: entrypoint,
+		ModTime:    modTime,
+		Manifest:   target.Manifest,
+	})
+	if err := index.Save(); err != nil {
+		return Version{}, err
+	}
+
+	lockfile.Set(extensionConfig.Origin, LockEntry{Sha256: target.Sha256, Commit: target.Commit})
+	if err := lockfile.Save(); err != nil {
+		return Version{}, err
+	}
+
+	return *target, nil
+}
+
+func copyFile(src string, dst string) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return err
+	}
+
+	return target.Close()
+}
@@ -0,0 +1,104 @@
+package extensions
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func scriptVersion(n int) string {
+	return fmt.Sprintf("#!/bin/sh\necho '{\"title\": \"v%d\", \"commands\": [{\"name\": \"hi\", \"title\": \"Say Hi\", \"mode\": \"detail\"}]}'\n", n)
+}
+
+func TestArchiveVersions(t *testing.T) {
+	server := &extensionServer{body: scriptVersion(1)}
+	cfg := installRemote(t, server)
+	cfg.KeepVersions = 3
+
+	for n := 2; n <= 5; n++ {
+		server.update(scriptVersion(n), "", "")
+		if _, err := Upgrade(cfg, "remote"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := ListVersions(cfg.Extensions["remote"].Origin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(versions) != 3 {
+		t.Fatalf("expected the versions to be pruned to 3, got %d", len(versions))
+	}
+
+	for i, version := range versions {
+		n := 5 - i
+		if version.ID != n || version.Sha256 != checksum(scriptVersion(n)) || version.Manifest.Title != fmt.Sprintf("v%d", n) {
+			t.Errorf("expected version %d to be archived, got %d (%s)", n, version.ID, version.Manifest.Title)
+		}
+
+		bts, err := os.ReadFile(version.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(bts) != scriptVersion(n) {
+			t.Errorf("expected the entrypoint of version %d to be archived", n)
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	server := &extensionServer{body: scriptVersion(1)}
+	cfg := installRemote(t, server)
+
+	server.update(scriptVersion(2), "", "")
+	if _, err := Upgrade(cfg, "remote"); err != nil {
+		t.Fatal(err)
+	}
+
+	version, err := Rollback(cfg, "remote", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version.ID != 1 {
+		t.Fatalf("expected the previous version to be restored, got %d", version.ID)
+	}
+
+	extension, err := LoadExtension(cfg, "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if extension.Manifest.Title != "v1" {
+		t.Errorf("expected the manifest of v1, got %s", extension.Manifest.Title)
+	}
+
+	bts, err := os.ReadFile(extension.Entrypoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(bts) != scriptVersion(1) {
+		t.Errorf("expected the entrypoint of v1 to be restored")
+	}
+
+	lockfile, err := LoadLockfile(LockPath(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry, _ := lockfile.Get(cfg.Extensions["remote"].Origin); entry.Sha256 != checksum(scriptVersion(1)) {
+		t.Errorf("expected the lockfile to pin v1, got %s", entry.Sha256)
+	}
+
+	// the version rolled back from is still available
+	if version, err := Rollback(cfg, "remote", 2); err != nil || version.ID != 2 {
+		t.Errorf("expected to restore version 2, got %d: %v", version.ID, err)
+	}
+
+	if _, err := Rollback(cfg, "remote", 42); err == nil {
+		t.Errorf("expected an unknown version to be rejected")
+	}
+}
@@ -1,6 +1,7 @@
 package fzf
 
 import (
+	"sort"
 	"unicode"
 
 	"github.com/junegunn/fzf/src/algo"
@@ -26,3 +27,24 @@ func Score(input, pattern string) int {
 
 	return res.Score
 }
+
+// Filter returns the items matching the pattern, best matches first.
+// All the items are returned if the pattern is empty.
+func Filter[T any](items []T, pattern string, value func(T) string) []T {
+	if pattern == "" {
+		return items
+	}
+
+	filtered := make([]T, 0)
+	for _, item := range items {
+		if Score(value(item), pattern) > 0 {
+			filtered = append(filtered, item)
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return Score(value(filtered[i]), pattern) > Score(value(filtered[j]), pattern)
+	})
+
+	return filtered
+}
@@ -13,6 +13,13 @@ import (
 
 var Path = filepath.Join(utils.CacheDir(), "history.json")
 
+// New returns an empty history, kept in memory
+func New() History {
+	return History{
+		entries: map[string]int64{},
+	}
+}
+
 type History struct {
 	entries map[string]int64
 	path    string
@@ -53,7 +60,12 @@ func (h History) Update(key string) {
 	h.entries[key] = time.Now().Unix()
 }
 
+// Save writes the history to its file, histories without path are only kept in memory
 func (h History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+
 	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
 		return err
 	}
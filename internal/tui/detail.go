@@ -18,8 +18,6 @@ import (
 )
 
 type Detail struct {
-	actionsFocused bool
-
 	isLoading bool
 	spinner   spinner.Model
 	viewport  viewport.Model
@@ -103,8 +101,8 @@ func (d *Detail) SetIsLoading(isLoading bool) tea.Cmd {
 func (c *Detail) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "tab":
+		switch {
+		case Keys.Matches(msg, KeyShowActions):
 			if c.statusBar.expanded {
 				break
 			}
@@ -114,14 +112,15 @@ func (c *Detail) Update(msg tea.Msg) (Page, tea.Cmd) {
 			}
 
 			c.statusBar.expanded = true
-			return c, c.input.Focus()
-		case "q":
-			if c.actionsFocused {
+			c.input.Focus()
+			return c, focusCursor(&c.input.Cursor)
+		case Keys.Matches(msg, KeyQuit):
+			if c.statusBar.expanded {
 				break
 			}
 
 			return c, PopPageCmd
-		case "esc":
+		case Keys.Matches(msg, KeyBack):
 			if c.statusBar.expanded {
 				c.statusBar.Reset()
 				c.input.Blur()
@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// WatchInterval is the delay between two checks of the watched files
+const WatchInterval = 500 * time.Millisecond
+
+// ExtensionChangedMsg is sent to every page of the stack when the extension under development was modified
+type ExtensionChangedMsg struct {
+	Manifest sunbeam.Manifest
+}
+
+type watchTickMsg struct{}
+
+// Watcher polls the modification times of files and directories, so that no platform specific api is needed
+type Watcher struct {
+	paths       []string
+	fingerprint string
+}
+
+func NewWatcher(paths ...string) (*Watcher, error) {
+	fingerprint, err := fingerprintPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		paths:       paths,
+		fingerprint: fingerprint,
+	}, nil
+}
+
+// Changed reports whether a watched file was created, modified or removed since the last call
+func (w *Watcher) Changed() (bool, error) {
+	fingerprint, err := fingerprintPaths(w.paths)
+	if err != nil {
+		return false, err
+	}
+
+	if fingerprint == w.fingerprint {
+		return false, nil
+	}
+
+	w.fingerprint = fingerprint
+	return true, nil
+}
+
+func fingerprintPaths(paths []string) (string, error) {
+	hash := sha1.New()
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				switch d.Name() {
+				case ".git", "node_modules":
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(hash, "%s %d %d\n", path, info.ModTime().UnixNano(), info.Size())
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DevServer wraps the page stack of an extension under development.
+// The manifest is extracted again whenever a watched file changes, and every page of the stack is notified without being dropped.
+type DevServer struct {
+	paginator *Paginator
+	watcher   *Watcher
+	extension extensions.Extension
+}
+
+func NewDevServer(extension extensions.Extension, root Page, watcher *Watcher) *DevServer {
+	return &DevServer{
+		paginator: NewPaginator(root),
+		watcher:   watcher,
+		extension: extension,
+	}
+}
+
+func (d *DevServer) Init() tea.Cmd {
+	return tea.Batch(d.paginator.Init(), d.tick())
+}
+
+func (d *DevServer) tick() tea.Cmd {
+	return tea.Tick(WatchInterval, func(time.Time) tea.Msg {
+		return watchTickMsg{}
+	})
+}
+
+func (d *DevServer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case watchTickMsg:
+		// editors often replace the file when saving, a missing file is only a transient state
+		changed, err := d.watcher.Changed()
+		if err != nil || !changed {
+			return d, d.tick()
+		}
+
+		return d, tea.Batch(d.tick(), func() tea.Msg {
+			manifest, err := d.extension.ExtractManifest()
+			if err != nil {
+				return fmt.Errorf("failed to extract manifest: %w", err)
+			}
+
+			return ExtensionChangedMsg{Manifest: manifest}
+		})
+	case ExtensionChangedMsg:
+		d.extension.Manifest = msg.Manifest
+		return d, d.paginator.Broadcast(msg)
+	}
+
+	_, cmd := d.paginator.Update(msg)
+	return d, cmd
+}
+
+func (d *DevServer) View() string {
+	return d.paginator.View()
+}
+
+func DrawDev(server *DevServer) error {
+	p := tea.NewProgram(server, tea.WithAltScreen())
+
+	_, err := p.Run()
+	return err
+}
@@ -1,6 +1,12 @@
 package tui
 
-import "github.com/pomdtr/sunbeam/pkg/sunbeam"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
 
 func NewErrorPage(err error, additionalActions ...sunbeam.Action) *Detail {
 	var actions []sunbeam.Action
@@ -12,6 +18,24 @@ func NewErrorPage(err error, additionalActions ...sunbeam.Action) *Detail {
 			Exit: true,
 		},
 	})
+
+	// link to the installation instructions of the missing requirements
+	var requirementsErr extensions.RequirementsError
+	if errors.As(err, &requirementsErr) {
+		for _, requirement := range requirementsErr.Missing {
+			if requirement.Link == "" {
+				continue
+			}
+
+			actions = append(actions, sunbeam.Action{
+				Title: fmt.Sprintf("Install %s", requirement.Name),
+				Type:  sunbeam.ActionTypeOpen,
+				Open: &sunbeam.OpenAction{
+					Url: requirement.Link,
+				},
+			})
+		}
+	}
 	actions = append(actions, additionalActions...)
 
 	detail := NewDetail(err.Error(), actions...)
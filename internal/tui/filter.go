@@ -1,7 +1,6 @@
 package tui
 
 import (
-	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
@@ -81,18 +80,7 @@ func (f *Filter) FilterItems(query string) {
 	if query == "" {
 		f.filtered = f.items
 	} else {
-		f.filtered = make([]FilterItem, 0)
-		for i := 0; i < len(f.items); i++ {
-			filterValue := f.items[i].FilterValue()
-			score := fzf.Score(filterValue, query)
-			if score > 0 {
-				f.filtered = append(f.filtered, f.items[i])
-			}
-		}
-
-		sort.SliceStable(f.filtered, func(i, j int) bool {
-			return fzf.Score(f.filtered[i].FilterValue(), query) > fzf.Score(f.filtered[j].FilterValue(), query)
-		})
+		f.filtered = fzf.Filter(f.items, query, FilterItem.FilterValue)
 	}
 
 	if f.cursor >= len(f.filtered) {
@@ -161,17 +149,17 @@ func (m Filter) View() string {
 func (f Filter) Update(msg tea.Msg) (Filter, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "down", "ctrl+j", "ctrl+n":
+		switch {
+		case Keys.Matches(msg, KeyCursorDown):
 			f.CursorDown()
-		case "up", "ctrl+k", "ctrl+p":
+		case Keys.Matches(msg, KeyCursorUp):
 			f.CursorUp()
-		case "ctrl+u":
+		case Keys.Matches(msg, KeyPageUp):
 			shift := min(f.nbVisibleItems(), f.cursor)
 			for i := 0; i < shift; i++ {
 				f.CursorUp()
 			}
-		case "ctrl+d":
+		case Keys.Matches(msg, KeyPageDown):
 			shift := min(f.nbVisibleItems(), len(f.filtered)-f.cursor-1)
 			for i := 0; i < shift; i++ {
 				f.CursorDown()
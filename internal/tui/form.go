@@ -2,8 +2,6 @@ package tui
 
 import (
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -29,34 +27,7 @@ type Form struct {
 }
 
 func ExtractPreferencesFromEnv(alias string, extension extensions.Extension) (map[string]any, error) {
-	var preferences = make(map[string]any)
-	for _, input := range extension.Manifest.Preferences {
-		env := fmt.Sprintf("%s_%s", strings.ToUpper(alias), strings.ToUpper(input.Name))
-		env = strings.ReplaceAll(env, "-", "_")
-		if value, ok := os.LookupEnv(env); ok {
-			switch input.Type {
-			case sunbeam.InputString:
-				preferences[input.Name] = value
-			case sunbeam.InputBoolean:
-				value, err := strconv.ParseBool(value)
-				if err != nil {
-					continue
-				}
-
-				preferences[input.Name] = value
-			case sunbeam.InputNumber:
-				value, err := strconv.ParseInt(value, 10, 64)
-				if err != nil {
-					return nil, err
-				}
-
-				preferences[input.Name] = value
-			}
-			continue
-		}
-	}
-
-	return preferences, nil
+	return extensions.PreferencesFromEnv(alias, extension.Manifest.Preferences)
 }
 
 func FindMissingPreferences(preferenceInputs []sunbeam.Input, values map[string]any) []sunbeam.Input {
@@ -175,16 +146,14 @@ func (c *Form) ScrollViewport() {
 func (c Form) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case Keys.Matches(msg, KeyBack):
 			return &c, func() tea.Msg {
 				return PopPageMsg{}
 			}
 		// Set focus to next input
-		case "tab", "shift+tab":
-			s := msg.String()
-
-			if s == "up" || s == "shift+tab" {
+		case Keys.Matches(msg, KeyNextField), Keys.Matches(msg, KeyPreviousField):
+			if Keys.Matches(msg, KeyPreviousField) {
 				c.focusIndex--
 			} else {
 				c.focusIndex++
@@ -214,7 +183,7 @@ func (c Form) Update(msg tea.Msg) (Page, tea.Cmd) {
 			}
 
 			return &c, tea.Batch(cmds...)
-		case "alt+enter":
+		case Keys.Matches(msg, KeySubmitForm):
 			return &c, func() tea.Msg {
 				values := make(map[string]any)
 				for _, input := range c.inputs {
@@ -296,6 +265,6 @@ func (c *Form) SetSize(width, height int) {
 
 func (c *Form) View() string {
 	separator := strings.Repeat("─", c.width)
-	submitRow := lipgloss.NewStyle().Align(lipgloss.Right).Padding(0, 1).Width(c.width).Render(fmt.Sprintf("%s · %s", renderAction("Submit", "alt+enter", false), renderAction("Focus Next", "tab", false)))
+	submitRow := lipgloss.NewStyle().Align(lipgloss.Right).Padding(0, 1).Width(c.width).Render(fmt.Sprintf("%s · %s", renderAction("Submit", Keys.Help(KeySubmitForm), false), renderAction("Focus Next", Keys.Help(KeyNextField), false)))
 	return lipgloss.JoinVertical(lipgloss.Left, c.viewport.View(), separator, submitRow)
 }
@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/acarl005/stripansi"
+	"github.com/charmbracelet/bubbles/cursor"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/muesli/termenv"
+)
+
+// Harness drives a page without a terminal, at a fixed size.
+// Messages are sent to the page stack as the bubbletea runtime would, and the commands they return are run until none is in flight.
+// Timers do not run in real time: they fire when the clock of the harness is advanced.
+type Harness struct {
+	paginator *Paginator
+	results   chan cmdResult
+	// pending is the number of commands in flight
+	pending int
+	now     time.Time
+	timers  []timer
+	exited  bool
+	// last is the view before the last update, shown once the page stack quit
+	last string
+	// OnMsg is called with every message received by the page stack, if set
+	OnMsg func(msg tea.Msg)
+}
+
+// cmdResult is a message returned by a command, done is set once the command is no longer in flight
+type cmdResult struct {
+	msg  tea.Msg
+	done bool
+}
+
+type timer struct {
+	at time.Time
+	fn func(time.Time) tea.Msg
+}
+
+// NewHarness initializes the page at the given size, and waits for it to settle.
+// Window titles are not written to the terminal while the harness is used, and cursors do not blink.
+func NewHarness(page Page, width, height int) *Harness {
+	termenv.SetDefaultOutput(termenv.NewOutput(io.Discard))
+	CursorMode = cursor.CursorStatic
+
+	h := &Harness{
+		paginator: NewPaginator(page),
+		results:   make(chan cmdResult, 64),
+		now:       time.Unix(0, 0),
+	}
+
+	h.run(h.paginator.Init())
+	h.Send(tea.WindowSizeMsg{Width: width, Height: height})
+	return h
+}
+
+// Send sends messages to the page stack one by one, and waits for it to settle after each of them
+func (h *Harness) Send(msgs ...tea.Msg) {
+	for _, msg := range msgs {
+		if h.exited {
+			return
+		}
+
+		h.update(msg)
+		h.wait()
+	}
+}
+
+// Type sends a key message for each rune of the text
+func (h *Harness) Type(text string) {
+	for _, r := range text {
+		h.Send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+// Press sends key messages from their names, as returned by tea.KeyMsg.String: enter, ctrl+k, alt+enter, q...
+func (h *Harness) Press(keys ...string) error {
+	for _, key := range keys {
+		msg, err := ParseKey(key)
+		if err != nil {
+			return err
+		}
+
+		h.Send(msg)
+	}
+
+	return nil
+}
+
+// Advance moves the clock of the harness forward, firing the timers that are due in order.
+// The page stack settles after each of them.
+func (h *Harness) Advance(d time.Duration) {
+	end := h.now.Add(d)
+	for !h.exited {
+		next := -1
+		for i, t := range h.timers {
+			if !t.at.After(end) && (next == -1 || t.at.Before(h.timers[next].at)) {
+				next = i
+			}
+		}
+
+		if next == -1 {
+			break
+		}
+
+		t := h.timers[next]
+		h.timers = append(h.timers[:next], h.timers[next+1:]...)
+		h.now = t.at
+		if msg := t.fn(t.at); msg != nil {
+			h.update(msg)
+		}
+		h.wait()
+	}
+
+	h.now = end
+}
+
+// Exited reports whether the page stack quit
+func (h *Harness) Exited() bool {
+	return h.exited
+}
+
+// View returns the rendered view of the current page, with its styles.
+// Once the page stack quit, the last view before it did is returned.
+func (h *Harness) View() string {
+	if h.exited {
+		return h.last
+	}
+
+	return h.paginator.View()
+}
+
+// Snapshot returns the view without styles nor trailing spaces, so that it can be compared with a golden file
+func (h *Harness) Snapshot() string {
+	lines := strings.Split(stripansi.Strip(h.View()), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+func (h *Harness) update(msg tea.Msg) {
+	if h.OnMsg != nil {
+		h.OnMsg(msg)
+	}
+
+	switch msg := msg.(type) {
+	case tea.QuitMsg:
+		h.exited = true
+		return
+	case delayMsg:
+		h.timers = append(h.timers, timer{at: h.now.Add(msg.d), fn: msg.fn})
+		return
+	}
+
+	// batches and sequences are both slices of commands, the sequence type is not exported
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		cmds := make([]tea.Cmd, v.Len())
+		for i := range cmds {
+			cmds[i] = v.Index(i).Interface().(tea.Cmd)
+		}
+
+		if _, ok := msg.(tea.BatchMsg); ok {
+			for _, cmd := range cmds {
+				h.run(cmd)
+			}
+			return
+		}
+
+		// the commands of a sequence run one after the other, the sequence is in flight until the last one returns
+		h.pending++
+		go func() {
+			for _, cmd := range cmds {
+				if cmd == nil {
+					continue
+				}
+				h.results <- cmdResult{msg: cmd()}
+			}
+			h.results <- cmdResult{done: true}
+		}()
+		return
+	}
+
+	h.last = h.paginator.View()
+	_, cmd := h.paginator.Update(msg)
+	h.run(cmd)
+}
+
+func (h *Harness) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+
+	h.pending++
+	go func() {
+		h.results <- cmdResult{msg: cmd(), done: true}
+	}()
+}
+
+// wait processes the messages returned by commands, until none is in flight
+func (h *Harness) wait() {
+	for !h.exited && h.pending > 0 {
+		res := <-h.results
+		if res.done {
+			h.pending--
+		}
+
+		if res.msg != nil {
+			h.update(res.msg)
+		}
+	}
+}
+
+// ParseKey returns the key message matching a key name, as returned by tea.KeyMsg.String
+func ParseKey(name string) (tea.KeyMsg, error) {
+	var msg tea.KeyMsg
+	if strings.HasPrefix(name, "alt+") && len(name) > len("alt+") {
+		msg.Alt = true
+		name = strings.TrimPrefix(name, "alt+")
+	}
+
+	if runes := []rune(name); len(runes) == 1 {
+		msg.Type = tea.KeyRunes
+		msg.Runes = runes
+		return msg, nil
+	}
+
+	if name == "space" {
+		msg.Type = tea.KeySpace
+		msg.Runes = []rune{' '}
+		return msg, nil
+	}
+
+	// key types are either control characters, or negative values for the other keys
+	for keyType := tea.KeyType(-64); keyType < 128; keyType++ {
+		if keyType.String() == name {
+			msg.Type = keyType
+			return msg, nil
+		}
+	}
+
+	return tea.KeyMsg{}, fmt.Errorf("unknown key: %s", name)
+}
+
+// CompareGolden compares a snapshot with the content of a golden file, which is written instead if update is set.
+// The error lists the differing lines, prefixed with - for the golden file and + for the snapshot.
+func CompareGolden(path string, snapshot string, update bool) error {
+	if update {
+		return os.WriteFile(path, []byte(snapshot), 0644)
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read golden file: %w", err)
+	}
+
+	if string(golden) == snapshot {
+		return nil
+	}
+
+	want := strings.Split(string(golden), "\n")
+	got := strings.Split(snapshot, "\n")
+
+	var diff strings.Builder
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			fmt.Fprintf(&diff, "- %s\n", want[i])
+		case i >= len(want):
+			fmt.Fprintf(&diff, "+ %s\n", got[i])
+		case want[i] != got[i]:
+			fmt.Fprintf(&diff, "- %s\n+ %s\n", want[i], got[i])
+		}
+	}
+
+	return fmt.Errorf("snapshot does not match %s:\n%s", path, diff.String())
+}
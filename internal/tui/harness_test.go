@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+var update = flag.Bool("update", false, "write the snapshots to the golden files")
+
+const (
+	harnessWidth  = 60
+	harnessHeight = 12
+)
+
+// assertGolden compares the snapshot of the harness with testdata/<name>.golden
+func assertGolden(t *testing.T, h *Harness, name string) {
+	t.Helper()
+
+	if err := CompareGolden(filepath.Join("testdata", name+".golden"), h.Snapshot(), *update); err != nil {
+		t.Error(err)
+	}
+}
+
+func press(t *testing.T, h *Harness, keys ...string) {
+	t.Helper()
+
+	if err := h.Press(keys...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func fruits() []sunbeam.ListItem {
+	var items []sunbeam.ListItem
+	for _, fruit := range []string{"Apple", "Banana", "Cherry", "Grape", "Lemon", "Mango", "Orange", "Peach"} {
+		items = append(items, sunbeam.ListItem{
+			Title:    fruit,
+			Subtitle: "Fruit",
+			Actions: []sunbeam.Action{
+				{Title: fmt.Sprintf("Copy %s", fruit), Type: sunbeam.ActionTypeCopy, Copy: &sunbeam.CopyAction{Text: fruit}},
+				{Title: "Open Wikipedia", Type: sunbeam.ActionTypeOpen, Open: &sunbeam.OpenAction{Url: "https://wikipedia.org/wiki/" + fruit}},
+			},
+		})
+	}
+
+	return items
+}
+
+func TestHarnessList(t *testing.T) {
+	h := NewHarness(NewList(fruits()...), harnessWidth, harnessHeight)
+	assertGolden(t, h, "list")
+
+	h.Type("an")
+	assertGolden(t, h, "list-filter")
+
+	press(t, h, "down")
+	assertGolden(t, h, "list-filter-down")
+}
+
+func TestHarnessActions(t *testing.T) {
+	h := NewHarness(NewList(fruits()...), harnessWidth, harnessHeight)
+
+	press(t, h, "tab")
+	assertGolden(t, h, "actions-expanded")
+
+	h.Type("wiki")
+	assertGolden(t, h, "actions-filter")
+
+	press(t, h, "esc")
+	assertGolden(t, h, "actions-collapsed")
+}
+
+func TestHarnessForm(t *testing.T) {
+	var submitted map[string]any
+	form := NewForm(func(values map[string]any) tea.Msg {
+		submitted = values
+		return nil
+	}, sunbeam.Input{Name: "name", Title: "Name", Type: sunbeam.InputString},
+		sunbeam.Input{Name: "count", Title: "Count", Type: sunbeam.InputNumber},
+		sunbeam.Input{Name: "public", Title: "Public", Type: sunbeam.InputBoolean},
+	)
+
+	h := NewHarness(form, harnessWidth, harnessHeight)
+	assertGolden(t, h, "form")
+
+	h.Type("sunbeam")
+	press(t, h, "tab")
+	h.Type("42")
+	assertGolden(t, h, "form-filled")
+
+	press(t, h, "alt+enter")
+	if submitted["name"] != "sunbeam" {
+		t.Errorf("expected the name to be submitted, got %v", submitted)
+	}
+}
+
+func TestHarnessError(t *testing.T) {
+	h := NewHarness(NewErrorPage(errors.New("failed to run command: exit status 1")), harnessWidth, harnessHeight)
+	assertGolden(t, h, "error")
+
+	press(t, h, "tab")
+	assertGolden(t, h, "error-actions")
+}
+
+func TestHarnessDetailScroll(t *testing.T) {
+	var text string
+	for i := 1; i <= 30; i++ {
+		text += fmt.Sprintf("Line %d\n", i)
+	}
+
+	h := NewHarness(NewDetail(text), harnessWidth, harnessHeight)
+	assertGolden(t, h, "detail")
+
+	press(t, h, "down", "down", "down")
+	assertGolden(t, h, "detail-scrolled")
+}
+
+// slowList loads its items from a command returning after a delay
+type slowList struct {
+	*List
+	delay time.Duration
+}
+
+type loadedMsg []sunbeam.ListItem
+
+func (l slowList) Init() tea.Cmd {
+	return tea.Batch(l.List.Init(), l.SetIsLoading(true), func() tea.Msg {
+		time.Sleep(l.delay)
+		return loadedMsg(fruits())
+	})
+}
+
+func (l slowList) Update(msg tea.Msg) (Page, tea.Cmd) {
+	if msg, ok := msg.(loadedMsg); ok {
+		l.SetItems(msg...)
+		return l, l.SetIsLoading(false)
+	}
+
+	page, cmd := l.List.Update(msg)
+	l.List = page.(*List)
+	return l, cmd
+}
+
+func TestHarnessWaitsForCommands(t *testing.T) {
+	h := NewHarness(slowList{List: NewList(), delay: 500 * time.Millisecond}, harnessWidth, harnessHeight)
+	assertGolden(t, h, "list")
+}
+
+func TestHarnessTimers(t *testing.T) {
+	var queries []string
+	list := NewList()
+	list.OnQueryChange = func(query string) tea.Cmd {
+		queries = append(queries, query)
+		return nil
+	}
+
+	h := NewHarness(list, harnessWidth, harnessHeight)
+	h.Type("abc")
+	if len(queries) != 0 {
+		t.Fatalf("expected the query change to be debounced, got %v", queries)
+	}
+
+	h.Advance(time.Second)
+	if len(queries) != 1 || queries[0] != "abc" {
+		t.Errorf("expected a single query change once the timers fired, got %v", queries)
+	}
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Help lists the active keybindings, along with the names used to remap them in the config
+type Help struct {
+	list *List
+}
+
+func NewHelp() *Help {
+	bindings := Keys.Bindings()
+	items := make([]sunbeam.ListItem, 0, len(bindings))
+	for _, binding := range bindings {
+		keys := strings.Join(binding.Keys, ", ")
+		if keys == "" {
+			keys = "unbound"
+		}
+
+		items = append(items, sunbeam.ListItem{
+			Id:          binding.Name,
+			Title:       binding.Description,
+			Subtitle:    binding.Name,
+			Accessories: []string{binding.Scope, keys},
+		})
+	}
+
+	list := NewList(items...)
+	list.SetEmptyText("No keybindings")
+
+	return &Help{
+		list: list,
+	}
+}
+
+func (c *Help) Init() tea.Cmd {
+	return c.list.Init()
+}
+
+func (c *Help) Focus() tea.Cmd {
+	return c.list.Focus()
+}
+
+func (c *Help) Blur() tea.Cmd {
+	return c.list.Blur()
+}
+
+func (c *Help) SetSize(width, height int) {
+	c.list.SetSize(width, height)
+}
+
+func (c *Help) Update(msg tea.Msg) (Page, tea.Cmd) {
+	page, cmd := c.list.Update(msg)
+	c.list = page.(*List)
+	return c, cmd
+}
+
+func (c *Help) View() string {
+	return c.list.View()
+}
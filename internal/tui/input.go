@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/cursor"
 	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -14,6 +15,15 @@ import (
 	"github.com/pomdtr/sunbeam/pkg/sunbeam"
 )
 
+// CursorMode is the mode of the cursor of text inputs, the harness keeps it static so that snapshots do not depend on the blink
+var CursorMode = cursor.CursorBlink
+
+// focusCursor applies the cursor mode when the input is focused, as the harness sets it once the pages are built
+func focusCursor(c *cursor.Model) tea.Cmd {
+	c.SetMode(CursorMode)
+	return c.Focus()
+}
+
 type Input interface {
 	Name() string
 	Title() string
@@ -36,6 +46,11 @@ type TextField struct {
 	placeholder string
 }
 
+func (ti *TextField) Focus() tea.Cmd {
+	ti.Model.Focus()
+	return focusCursor(&ti.Cursor)
+}
+
 func NewTextField(input sunbeam.Input, secure bool) *TextField {
 	ti := textinput.New()
 	ti.Prompt = ""
@@ -86,8 +101,8 @@ func (ti *TextField) Value() any {
 func (ti *TextField) Update(msg tea.Msg) (Input, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+e":
+		switch {
+		case Keys.Matches(msg, KeyOpenEditor):
 			if !ti.Model.Focused() {
 				break
 			}
@@ -122,6 +137,11 @@ type TextArea struct {
 	name  string
 }
 
+func (ta *TextArea) Focus() tea.Cmd {
+	ta.Model.Focus()
+	return focusCursor(&ta.Cursor)
+}
+
 func (ta *TextArea) Title() string {
 	return ta.title
 }
@@ -165,8 +185,8 @@ func (ta *TextArea) Value() any {
 func (ta *TextArea) Update(msg tea.Msg) (Input, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+e":
+		switch {
+		case Keys.Matches(msg, KeyOpenEditor):
 			if !ta.Model.Focused() {
 				break
 			}
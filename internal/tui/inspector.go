@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Inspector lists the extension invocations recorded in debug mode, the most recent first
+type Inspector struct {
+	list *List
+}
+
+func NewInspector() *Inspector {
+	list := NewList()
+	list.SetEmptyText("No invocations recorded")
+	list.SetShowDetail(true)
+
+	return &Inspector{
+		list: list,
+	}
+}
+
+func (c *Inspector) Init() tea.Cmd {
+	c.Reload()
+	return c.list.Init()
+}
+
+func (c *Inspector) Reload() {
+	invocations := extensions.Invocations()
+	items := make([]sunbeam.ListItem, 0, len(invocations))
+	for i := len(invocations) - 1; i >= 0; i-- {
+		items = append(items, invocationItem(invocations[i]))
+	}
+
+	c.list.SetItems(items...)
+}
+
+func invocationItem(invocation extensions.Invocation) sunbeam.ListItem {
+	title := invocation.Alias
+	if title == "" {
+		title = filepath.Base(invocation.Entrypoint)
+	}
+
+	subtitle := invocation.Command
+	if subtitle == "" {
+		subtitle = "manifest"
+	}
+
+	status := fmt.Sprintf("exit %d", invocation.ExitCode)
+	if invocation.ValidationError != "" {
+		status = "invalid"
+	}
+
+	actions := []sunbeam.Action{
+		{
+			Title: "Copy Stdout",
+			Type:  sunbeam.ActionTypeCopy,
+			Copy:  &sunbeam.CopyAction{Text: invocation.Stdout},
+		},
+	}
+
+	if invocation.Payload != "" {
+		actions = append(actions, sunbeam.Action{
+			Title: "Copy Payload",
+			Key:   "p",
+			Type:  sunbeam.ActionTypeCopy,
+			Copy:  &sunbeam.CopyAction{Text: invocation.Payload},
+		})
+	}
+
+	if invocation.Stderr != "" {
+		actions = append(actions, sunbeam.Action{
+			Title: "Copy Stderr",
+			Key:   "e",
+			Type:  sunbeam.ActionTypeCopy,
+			Copy:  &sunbeam.CopyAction{Text: invocation.Stderr},
+		})
+	}
+
+	return sunbeam.ListItem{
+		Id:          fmt.Sprintf("%d", invocation.ID),
+		Title:       title,
+		Subtitle:    subtitle,
+		Accessories: []string{status, invocation.Duration.Round(time.Millisecond).String()},
+		Detail: sunbeam.ListItemDetail{
+			Markdown: invocationMarkdown(invocation),
+		},
+		Actions: actions,
+	}
+}
+
+func invocationMarkdown(invocation extensions.Invocation) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Entrypoint:** `%s`\n\n", invocation.Entrypoint)
+	fmt.Fprintf(&b, "**Started At:** %s\n\n", invocation.StartedAt.Format(time.TimeOnly))
+	fmt.Fprintf(&b, "**Duration:** %s\n\n", invocation.Duration.Round(time.Millisecond))
+	fmt.Fprintf(&b, "**Exit Code:** %d\n\n", invocation.ExitCode)
+
+	switch {
+	case invocation.Schema == "":
+		b.WriteString("**Validation:** skipped\n\n")
+	case invocation.ValidationError != "":
+		fmt.Fprintf(&b, "**Validation:** invalid %s\n\n```\n%s\n```\n\n", invocation.Schema, invocation.ValidationError)
+	default:
+		fmt.Fprintf(&b, "**Validation:** valid %s\n\n", invocation.Schema)
+	}
+
+	if invocation.Payload != "" {
+		fmt.Fprintf(&b, "## Payload\n\n```json\n%s\n```\n\n", extensions.PrettyJSON(invocation.Payload))
+	}
+
+	if invocation.Stderr != "" {
+		fmt.Fprintf(&b, "## Stderr\n\n```\n%s\n```\n\n", strings.TrimSpace(invocation.Stderr))
+	}
+
+	fmt.Fprintf(&b, "## Stdout\n\n```json\n%s\n```\n", extensions.PrettyJSON(invocation.Stdout))
+	return b.String()
+}
+
+func (c *Inspector) Focus() tea.Cmd {
+	return c.list.Focus()
+}
+
+func (c *Inspector) Blur() tea.Cmd {
+	return c.list.Blur()
+}
+
+func (c *Inspector) SetSize(width, height int) {
+	c.list.SetSize(width, height)
+}
+
+func (c *Inspector) Update(msg tea.Msg) (Page, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case Keys.Matches(msg, KeyReload):
+			c.Reload()
+			return c, nil
+		}
+	case sunbeam.Action:
+		if msg.Type != sunbeam.ActionTypeCopy {
+			return c, nil
+		}
+
+		return c, func() tea.Msg {
+			if err := clipboard.WriteAll(msg.Copy.Text); err != nil {
+				return err
+			}
+
+			return ShowNotificationMsg{"Copied!"}
+		}
+	}
+
+	page, cmd := c.list.Update(msg)
+	c.list = page.(*List)
+	return c, cmd
+}
+
+func (c *Inspector) View() string {
+	return c.list.View()
+}
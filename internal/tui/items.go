@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/utils"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// OnelinerListItems returns the root items of the oneliners of the config
+func OnelinerListItems(oneliners []config.Oneliner) []sunbeam.ListItem {
+	var items []sunbeam.ListItem
+	for _, oneliner := range oneliners {
+		item := sunbeam.ListItem{
+			Id:          fmt.Sprintf("oneliner - %s", oneliner.Title),
+			Title:       oneliner.Title,
+			Accessories: []string{"Oneliner"},
+			Actions: []sunbeam.Action{
+				{
+					Title: "Run",
+					Type:  sunbeam.ActionTypeExec,
+					Exec: &sunbeam.ExecAction{
+						Command:     oneliner.Command,
+						Interactive: oneliner.Interactive,
+						Dir:         oneliner.Cwd,
+						Exit:        oneliner.Exit,
+					},
+				},
+				{
+					Title: "Copy Command",
+					Key:   "c",
+					Type:  sunbeam.ActionTypeCopy,
+					Copy: &sunbeam.CopyAction{
+						Text: oneliner.Command,
+					},
+				},
+			},
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// ExtensionListItems returns the root items of an extension: the root items of its config, and its root commands
+func ExtensionListItems(alias string, extension extensions.Extension, extensionConfig config.ExtensionConfig) []sunbeam.ListItem {
+	var items []sunbeam.ListItem
+
+	for _, rootItem := range extensionConfig.Root {
+		items = append(items, sunbeam.ListItem{
+			Id:          fmt.Sprintf("%s - %s", alias, rootItem.Title),
+			Title:       rootItem.Title,
+			Subtitle:    extension.Manifest.Title,
+			Accessories: []string{"Command"},
+			Actions: []sunbeam.Action{
+				{
+					Title: "Run",
+					Type:  sunbeam.ActionTypeRun,
+					Run:   &sunbeam.RunAction{Extension: alias, Command: rootItem.Command, Params: rootItem.Params},
+				},
+			},
+		})
+	}
+
+	for _, command := range extension.RootCommands() {
+		item := sunbeam.ListItem{
+			Id:          fmt.Sprintf("%s - %s", alias, command.Name),
+			Title:       command.Title,
+			Subtitle:    extension.Manifest.Title,
+			Accessories: []string{"Command"},
+			Actions: []sunbeam.Action{
+				{
+					Title: "Run",
+					Type:  sunbeam.ActionTypeRun,
+					Run:   &sunbeam.RunAction{Extension: alias, Command: command.Name},
+				},
+			},
+		}
+
+		if !extensions.IsRemote(extensionConfig.Origin) {
+			item.Actions = append(item.Actions, sunbeam.Action{
+				Title: "Edit Extension",
+				Key:   "e",
+				Type:  sunbeam.ActionTypeEdit,
+				Edit: &sunbeam.EditAction{
+					Path:   extension.Entrypoint,
+					Reload: true,
+				},
+			})
+		} else {
+			item.Actions = append(item.Actions, sunbeam.Action{
+				Title: "View Source",
+				Key:   "c",
+				Type:  sunbeam.ActionTypeExec,
+				Exec:  &sunbeam.ExecAction{Command: fmt.Sprintf("curl %s | %s", extensionConfig.Origin, utils.FindPager()), Interactive: true},
+			})
+		}
+
+		if len(extension.Manifest.Preferences) > 0 {
+			item.Actions = append(item.Actions, sunbeam.Action{
+				Title:  "Configure Extension",
+				Key:    "s",
+				Type:   sunbeam.ActionTypeConfig,
+				Config: &sunbeam.ConfigAction{Extension: alias},
+			})
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
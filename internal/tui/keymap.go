@@ -0,0 +1,282 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Names of the actions that can be bound to keys, in the keybindings section of the config
+const (
+	KeyInspector        = "inspector"
+	KeyHelp             = "help"
+	KeyBack             = "back"
+	KeyReload           = "reload"
+	KeyEdit             = "edit"
+	KeyShowActions      = "showActions"
+	KeyToggleDetail     = "toggleDetail"
+	KeyScrollDetailDown = "scrollDetailDown"
+	KeyScrollDetailUp   = "scrollDetailUp"
+	KeyCursorDown       = "cursorDown"
+	KeyCursorUp         = "cursorUp"
+	KeyPageDown         = "pageDown"
+	KeyPageUp           = "pageUp"
+	KeyRunAction        = "runAction"
+	KeySecondaryAction  = "secondaryAction"
+	KeyNextAction       = "nextAction"
+	KeyPreviousAction   = "previousAction"
+	KeyActionModifier   = "actionModifier"
+	KeyClosePage        = "closePage"
+	KeyQuit             = "quit"
+	KeySubmitForm       = "submitForm"
+	KeyNextField        = "nextField"
+	KeyPreviousField    = "previousField"
+	KeyOpenEditor       = "openEditor"
+)
+
+// Scopes group the bindings handled by the same component, in the same state.
+// Keys must be unique among the scopes active together on a page, see pageScopes.
+const (
+	ScopeGlobal           = "global"
+	ScopePage             = "page"
+	ScopeList             = "list"
+	ScopeFilter           = "filter"
+	ScopeActions          = "actions"
+	ScopeCollapsedActions = "collapsedActions"
+	ScopeExpandedActions  = "expandedActions"
+	ScopeDetail           = "detail"
+	ScopeForm             = "form"
+	ScopeInput            = "input"
+)
+
+// pageScopes lists the scopes active together in each state of the pages
+var pageScopes = []struct {
+	Page   string
+	Scopes []string
+}{
+	{Page: "list", Scopes: []string{ScopeGlobal, ScopePage, ScopeList, ScopeFilter, ScopeActions, ScopeCollapsedActions}},
+	{Page: "list with expanded actions", Scopes: []string{ScopeGlobal, ScopePage, ScopeList, ScopeFilter, ScopeActions, ScopeExpandedActions}},
+	{Page: "detail", Scopes: []string{ScopeGlobal, ScopePage, ScopeDetail, ScopeActions, ScopeCollapsedActions}},
+	{Page: "detail with expanded actions", Scopes: []string{ScopeGlobal, ScopePage, ScopeActions, ScopeExpandedActions}},
+	{Page: "form", Scopes: []string{ScopeGlobal, ScopePage, ScopeForm, ScopeInput}},
+}
+
+// Keybinding triggers a named action with any of its keys.
+// The keys of a modifier binding are prefixed to the keys of the actions: alt for alt+c.
+type Keybinding struct {
+	Name        string
+	Description string
+	Scope       string
+	Keys        []string
+	Modifier    bool
+}
+
+// DefaultKeybindings are the bindings used when the config does not override them
+var DefaultKeybindings = []Keybinding{
+	{Name: KeyInspector, Description: "Toggle the inspector, in debug mode", Scope: ScopeGlobal, Keys: []string{"ctrl+o"}},
+	{Name: KeyHelp, Description: "Toggle the list of keybindings", Scope: ScopeGlobal, Keys: []string{"f1"}},
+	{Name: KeyBack, Description: "Go back to the previous page", Scope: ScopePage, Keys: []string{"esc"}},
+	{Name: KeyReload, Description: "Reload the page", Scope: ScopePage, Keys: []string{"ctrl+r"}},
+	{Name: KeyEdit, Description: "Edit the config or the extension", Scope: ScopePage, Keys: []string{"ctrl+s"}},
+	{Name: KeyShowActions, Description: "Show the actions", Scope: ScopeCollapsedActions, Keys: []string{"tab"}},
+	{Name: KeyToggleDetail, Description: "Toggle the detail of the items", Scope: ScopeList, Keys: []string{"ctrl+p"}},
+	{Name: KeyScrollDetailDown, Description: "Scroll the detail down", Scope: ScopeList, Keys: []string{"ctrl+j"}},
+	{Name: KeyScrollDetailUp, Description: "Scroll the detail up", Scope: ScopeList, Keys: []string{"ctrl+k"}},
+	{Name: KeyCursorDown, Description: "Select the next item", Scope: ScopeFilter, Keys: []string{"down", "ctrl+n"}},
+	{Name: KeyCursorUp, Description: "Select the previous item", Scope: ScopeFilter, Keys: []string{"up"}},
+	{Name: KeyPageDown, Description: "Select the item one page down", Scope: ScopeFilter, Keys: []string{"ctrl+d"}},
+	{Name: KeyPageUp, Description: "Select the item one page up", Scope: ScopeFilter, Keys: []string{"ctrl+u"}},
+	{Name: KeyRunAction, Description: "Run the selected action", Scope: ScopeActions, Keys: []string{"enter"}},
+	{Name: KeySecondaryAction, Description: "Run the second action", Scope: ScopeActions, Keys: []string{"alt+enter"}},
+	{Name: KeyNextAction, Description: "Select the next action", Scope: ScopeExpandedActions, Keys: []string{"tab", "right"}},
+	{Name: KeyPreviousAction, Description: "Select the previous action", Scope: ScopeExpandedActions, Keys: []string{"shift+tab", "left"}},
+	{Name: KeyActionModifier, Description: "Run an action from its key", Scope: ScopeActions, Keys: []string{"alt"}, Modifier: true},
+	{Name: KeyClosePage, Description: "Close the page", Scope: ScopeCollapsedActions, Keys: []string{}},
+	{Name: KeyQuit, Description: "Close the detail", Scope: ScopeDetail, Keys: []string{"q"}},
+	{Name: KeySubmitForm, Description: "Submit the form", Scope: ScopeForm, Keys: []string{"alt+enter"}},
+	{Name: KeyNextField, Description: "Focus the next field", Scope: ScopeForm, Keys: []string{"tab"}},
+	{Name: KeyPreviousField, Description: "Focus the previous field", Scope: ScopeForm, Keys: []string{"shift+tab"}},
+	{Name: KeyOpenEditor, Description: "Edit the field in your editor", Scope: ScopeInput, Keys: []string{"ctrl+e"}},
+}
+
+// Keys is the active keymap, it is replaced by the one of the config with SetKeymap
+var Keys = Keymap{bindings: DefaultKeybindings}
+
+// SetKeymap loads the keybindings of the config, and makes them active
+func SetKeymap(overrides map[string][]string) error {
+	keymap, err := LoadKeymap(overrides)
+	if err != nil {
+		return err
+	}
+
+	Keys = keymap
+	return nil
+}
+
+type Keymap struct {
+	bindings []Keybinding
+}
+
+// LoadKeymap overrides the keys of the default bindings, and checks that no key is bound twice on the same page
+func LoadKeymap(overrides map[string][]string) (Keymap, error) {
+	bindings := make([]Keybinding, len(DefaultKeybindings))
+	copy(bindings, DefaultKeybindings)
+
+	for name, keys := range overrides {
+		idx := -1
+		for i, binding := range bindings {
+			if binding.Name == name {
+				idx = i
+				break
+			}
+		}
+
+		if idx == -1 {
+			return Keymap{}, fmt.Errorf("unknown keybinding: %s", name)
+		}
+
+		for _, key := range keys {
+			if err := checkKey(key, bindings[idx].Modifier); err != nil {
+				return Keymap{}, fmt.Errorf("invalid keybinding %s: %w", name, err)
+			}
+		}
+
+		bindings[idx].Keys = keys
+	}
+
+	for _, page := range pageScopes {
+		var active []Keybinding
+		for _, binding := range bindings {
+			if slices.Contains(page.Scopes, binding.Scope) {
+				active = append(active, binding)
+			}
+		}
+
+		for i, a := range active {
+			for _, b := range active[i+1:] {
+				if key, ok := conflict(a, b); ok {
+					return Keymap{}, fmt.Errorf("keybindings %s and %s are both bound to %s on the %s page", a.Name, b.Name, key, page.Page)
+				}
+			}
+		}
+	}
+
+	return Keymap{bindings: bindings}, nil
+}
+
+func checkKey(key string, modifier bool) error {
+	if modifier {
+		if key != "alt" && key != "ctrl" {
+			return fmt.Errorf("modifier must be alt or ctrl, got %s", key)
+		}
+		return nil
+	}
+
+	if key == "ctrl+c" {
+		return fmt.Errorf("ctrl+c is reserved to quit")
+	}
+
+	if _, err := ParseKey(key); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// conflict returns a key of a that also triggers b
+func conflict(a, b Keybinding) (string, bool) {
+	if a.Modifier || b.Modifier {
+		if a.Modifier && b.Modifier {
+			return "", false
+		}
+
+		modifier, other := a, b
+		if b.Modifier {
+			modifier, other = b, a
+		}
+
+		for _, prefix := range modifier.Keys {
+			for _, key := range other.Keys {
+				if rest, ok := strings.CutPrefix(key, prefix+"+"); ok && utf8.RuneCountInString(rest) == 1 {
+					return key, true
+				}
+			}
+		}
+
+		return "", false
+	}
+
+	for _, key := range a.Keys {
+		for _, other := range b.Keys {
+			if key == other {
+				return key, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// Bindings returns the active bindings, in the order of the defaults
+func (k Keymap) Bindings() []Keybinding {
+	return k.bindings
+}
+
+func (k Keymap) binding(name string) Keybinding {
+	for _, binding := range k.bindings {
+		if binding.Name == name {
+			return binding
+		}
+	}
+
+	return Keybinding{}
+}
+
+// Matches reports whether the key triggers the named action
+func (k Keymap) Matches(msg tea.KeyMsg, name string) bool {
+	for _, key := range k.binding(name).Keys {
+		if key == msg.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Help returns the first key of the named action, to be shown next to it
+func (k Keymap) Help(name string) string {
+	keys := k.binding(name).Keys
+	if len(keys) == 0 {
+		return ""
+	}
+
+	return keys[0]
+}
+
+// MatchesAction reports whether the key triggers the action with the given key, through the action modifier
+func (k Keymap) MatchesAction(msg tea.KeyMsg, actionKey string) bool {
+	if actionKey == "" {
+		return false
+	}
+
+	for _, modifier := range k.binding(KeyActionModifier).Keys {
+		if fmt.Sprintf("%s+%s", modifier, actionKey) == msg.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ActionHelp returns the key triggering the action with the given key, to be shown next to it
+func (k Keymap) ActionHelp(actionKey string) string {
+	modifier := k.Help(KeyActionModifier)
+	if actionKey == "" || modifier == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s+%s", modifier, actionKey)
+}
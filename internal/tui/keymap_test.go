@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// setKeymap activates the keybindings for the duration of the test
+func setKeymap(t *testing.T, overrides map[string][]string) {
+	t.Helper()
+
+	keys := Keys
+	if err := SetKeymap(overrides); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Keys = keys })
+}
+
+func TestLoadKeymapDefaults(t *testing.T) {
+	if _, err := LoadKeymap(nil); err != nil {
+		t.Fatalf("expected the default keybindings to be valid: %v", err)
+	}
+}
+
+func TestLoadKeymapConflicts(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		overrides map[string][]string
+		conflict  string
+	}{
+		{name: "list and filter", overrides: map[string][]string{KeyToggleDetail: {"ctrl+d"}}, conflict: "pageDown"},
+		{name: "page and actions", overrides: map[string][]string{KeyReload: {"enter"}}, conflict: "runAction"},
+		{name: "global and form", overrides: map[string][]string{KeyHelp: {"ctrl+e"}}, conflict: "openEditor"},
+		{name: "modifier", overrides: map[string][]string{KeyToggleDetail: {"alt+t"}}, conflict: "actionModifier"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadKeymap(tc.overrides)
+			if err == nil {
+				t.Fatalf("expected a conflict with %s", tc.conflict)
+			}
+
+			if !strings.Contains(err.Error(), tc.conflict) {
+				t.Errorf("expected a conflict with %s, got %v", tc.conflict, err)
+			}
+		})
+	}
+}
+
+func TestLoadKeymapExclusiveStates(t *testing.T) {
+	// the actions are either collapsed or expanded, and forms do not show them
+	for _, overrides := range []map[string][]string{
+		{KeyShowActions: {"ctrl+a"}, KeyNextAction: {"ctrl+a"}},
+		{KeySubmitForm: {"enter"}},
+		{KeyQuit: {"ctrl+n"}},
+	} {
+		if _, err := LoadKeymap(overrides); err != nil {
+			t.Errorf("expected %v to be valid: %v", overrides, err)
+		}
+	}
+}
+
+func TestNextActionKeys(t *testing.T) {
+	setKeymap(t, map[string][]string{KeyNextAction: {"ctrl+l"}, KeyPreviousAction: {"ctrl+h"}})
+
+	var actions []sunbeam.Action
+	h := NewHarness(NewList(fruits()...), harnessWidth, harnessHeight)
+	h.OnMsg = func(msg tea.Msg) {
+		if action, ok := msg.(sunbeam.Action); ok {
+			actions = append(actions, action)
+		}
+	}
+
+	// right is no longer bound, it only moves the cursor of the search input
+	press(t, h, "tab", "right", "ctrl+l", "ctrl+l", "ctrl+h", "enter")
+	if len(actions) != 1 || actions[0].Title != "Open Wikipedia" {
+		t.Errorf("expected the second action to run, got %v", actions)
+	}
+}
@@ -0,0 +1,202 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// KeyScript is run by Draw instead of reading keys from the terminal, if set.
+// Steps are separated by commas: "type gh, enter, down, tab, enter".
+// Quoted text keeps its commas and surrounding spaces: type "a, b ". Outside of quotes, a backslash escapes the next character: type a\, b.
+var KeyScript string
+
+const (
+	KeyScriptWidth  = 100
+	KeyScriptHeight = 30
+)
+
+// KeyScriptResult is the state of the page stack once the key script is done
+type KeyScriptResult struct {
+	Screen  string           `json:"screen"`
+	Actions []sunbeam.Action `json:"actions"`
+	Exited  bool             `json:"exited"`
+}
+
+// RunKeyScript drives the page with the steps of the script, and returns the final screen and the actions that were run.
+// A step is either a key name, "type <text>" or "wait <duration>", which fires the timers due within the duration, like the debounce of queries.
+// The whole script is parsed before the first step runs.
+func RunKeyScript(page Page, script string) (KeyScriptResult, error) {
+	steps, err := parseKeyScript(script)
+	if err != nil {
+		return KeyScriptResult{}, err
+	}
+
+	result := KeyScriptResult{
+		Actions: make([]sunbeam.Action, 0),
+	}
+
+	h := NewHarness(page, KeyScriptWidth, KeyScriptHeight)
+	h.OnMsg = func(msg tea.Msg) {
+		if action, ok := msg.(sunbeam.Action); ok {
+			result.Actions = append(result.Actions, action)
+		}
+	}
+
+	for _, step := range steps {
+		if h.Exited() {
+			break
+		}
+
+		switch step.kind {
+		case keyStepType:
+			h.Type(step.text)
+		case keyStepWait:
+			h.Advance(step.duration)
+		default:
+			if err := h.Press(step.text); err != nil {
+				return KeyScriptResult{}, fmt.Errorf("invalid step %q: %w", step.raw, err)
+			}
+		}
+	}
+
+	result.Screen = h.Snapshot()
+	result.Exited = h.Exited()
+	return result, nil
+}
+
+type keyStepKind int
+
+const (
+	keyStepPress keyStepKind = iota
+	keyStepType
+	keyStepWait
+)
+
+type keyStep struct {
+	kind     keyStepKind
+	raw      string
+	text     string
+	duration time.Duration
+}
+
+// parseKeyScript splits the script on the commas which are neither quoted nor escaped, and parses each step
+func parseKeyScript(script string) ([]keyStep, error) {
+	var raws []string
+	var current strings.Builder
+	var quoted bool
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("invalid key script: trailing backslash")
+			}
+
+			current.WriteRune(r)
+			current.WriteRune(runes[i+1])
+			i++
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case r == ',' && !quoted:
+			raws = append(raws, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("invalid key script: unterminated quote")
+	}
+	raws = append(raws, current.String())
+
+	var steps []keyStep
+	for _, raw := range raws {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		step, err := parseKeyStep(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid step %q: %w", raw, err)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+func parseKeyStep(raw string) (keyStep, error) {
+	step := keyStep{raw: raw}
+	switch {
+	case strings.HasPrefix(raw, "type "):
+		text, err := unquoteStep(strings.TrimLeft(strings.TrimPrefix(raw, "type "), " "))
+		if err != nil {
+			return keyStep{}, err
+		}
+
+		step.kind = keyStepType
+		step.text = text
+	case strings.HasPrefix(raw, "wait "):
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(raw, "wait ")))
+		if err != nil {
+			return keyStep{}, err
+		}
+
+		step.kind = keyStepWait
+		step.duration = d
+	default:
+		key, err := unquoteStep(raw)
+		if err != nil {
+			return keyStep{}, err
+		}
+
+		step.kind = keyStepPress
+		step.text = key
+	}
+
+	return step, nil
+}
+
+// unquoteStep returns the text of a step, quoted text follows the syntax of go strings, backslashes escape the next character otherwise
+func unquoteStep(text string) (string, error) {
+	if strings.HasPrefix(text, `"`) {
+		return strconv.Unquote(text)
+	}
+
+	var unquoted strings.Builder
+	escaped := false
+	for _, r := range text {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+
+		unquoted.WriteRune(r)
+		escaped = false
+	}
+
+	return unquoted.String(), nil
+}
+
+func drawKeyScript(page Page, w io.Writer) error {
+	result, err := RunKeyScript(page, KeyScript)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(result)
+}
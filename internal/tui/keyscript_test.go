@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseKeyScript(t *testing.T) {
+	testCases := []struct {
+		script   string
+		expected []keyStep
+	}{
+		{
+			script: "type gh, enter, down",
+			expected: []keyStep{
+				{kind: keyStepType, text: "gh"},
+				{kind: keyStepPress, text: "enter"},
+				{kind: keyStepPress, text: "down"},
+			},
+		},
+		{
+			script:   " , tab,, ",
+			expected: []keyStep{{kind: keyStepPress, text: "tab"}},
+		},
+		{
+			script:   `type "a, b "`,
+			expected: []keyStep{{kind: keyStepType, text: "a, b "}},
+		},
+		{
+			script:   `type "  padded", enter`,
+			expected: []keyStep{{kind: keyStepType, text: "  padded"}, {kind: keyStepPress, text: "enter"}},
+		},
+		{
+			script:   `type "say \"hi\", \\o/"`,
+			expected: []keyStep{{kind: keyStepType, text: `say "hi", \o/`}},
+		},
+		{
+			script:   `type a\, b, enter`,
+			expected: []keyStep{{kind: keyStepType, text: "a, b"}, {kind: keyStepPress, text: "enter"}},
+		},
+		{
+			script:   `type it\"s, ctrl+\,`,
+			expected: []keyStep{{kind: keyStepType, text: `it"s`}, {kind: keyStepPress, text: "ctrl+,"}},
+		},
+		{
+			script:   "wait 300ms, wait  2s",
+			expected: []keyStep{{kind: keyStepWait, duration: 300 * time.Millisecond}, {kind: keyStepWait, duration: 2 * time.Second}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.script, func(t *testing.T) {
+			steps, err := parseKeyScript(tc.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(steps) != len(tc.expected) {
+				t.Fatalf("expected %d steps, got %d: %+v", len(tc.expected), len(steps), steps)
+			}
+
+			for i, step := range steps {
+				expected := tc.expected[i]
+				if step.kind != expected.kind || step.text != expected.text || step.duration != expected.duration {
+					t.Errorf("expected step %d to be %+v, got %+v", i, expected, step)
+				}
+			}
+		})
+	}
+}
+
+func TestParseKeyScriptInvalid(t *testing.T) {
+	testCases := []struct {
+		script string
+		err    string
+	}{
+		{script: `type "a, b`, err: "unterminated quote"},
+		{script: `type a\`, err: "trailing backslash"},
+		{script: "type a, wait soon", err: `invalid step "wait soon"`},
+		{script: "wait -", err: `invalid step "wait -"`},
+		{script: `type "a" b`, err: `invalid step "type \"a\" b"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.script, func(t *testing.T) {
+			_, err := parseKeyScript(tc.script)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestRunKeyScript(t *testing.T) {
+	var queries []string
+	list := NewList(fruits()...)
+	list.OnQueryChange = func(query string) tea.Cmd {
+		queries = append(queries, query)
+		return nil
+	}
+
+	result, err := RunKeyScript(list, `type " an, ", wait 1s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(queries) != 1 || queries[0] != " an, " {
+		t.Errorf("expected the quoted text to be typed as is, got %q", queries)
+	}
+
+	// trailing spaces are trimmed from snapshots
+	if !strings.Contains(result.Screen, " an,") {
+		t.Errorf("expected the query to be shown:\n%s", result.Screen)
+	}
+
+	result, err = RunKeyScript(NewList(fruits()...), "type cherry, enter")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Actions) != 1 || result.Actions[0].Title != "Copy Cherry" {
+		t.Errorf("expected the primary action of the selected item to run, got %+v", result.Actions)
+	}
+}
+
+func TestRunKeyScriptInvalid(t *testing.T) {
+	testCases := []struct {
+		script string
+		err    string
+	}{
+		{script: "type ch, notakey", err: `invalid step "notakey"`},
+		{script: "type ch, wait soon", err: `invalid step "wait soon"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.script, func(t *testing.T) {
+			if _, err := RunKeyScript(NewList(fruits()...), tc.script); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+
+	// the script is parsed before it runs, an invalid step does not leave the page half driven
+	var queries []string
+	list := NewList(fruits()...)
+	list.OnQueryChange = func(query string) tea.Cmd {
+		queries = append(queries, query)
+		return nil
+	}
+
+	if _, err := RunKeyScript(list, "type ch, wait 1s, wait soon"); err == nil {
+		t.Fatalf("expected an invalid duration to be rejected")
+	}
+
+	if len(queries) > 0 {
+		t.Errorf("expected no step to run, got queries %q", queries)
+	}
+}
@@ -140,7 +140,8 @@ func (l *List) SetEmptyText(text string) {
 }
 
 func (c *List) Init() tea.Cmd {
-	return c.input.Focus()
+	c.input.Focus()
+	return focusCursor(&c.input.Cursor)
 }
 
 func (c *List) Focus() tea.Cmd {
@@ -149,7 +150,8 @@ func (c *List) Focus() tea.Cmd {
 	c.input.Placeholder = "Search Items..."
 	c.input.SetValue(c.query)
 
-	return c.input.Focus()
+	c.input.Focus()
+	return focusCursor(&c.input.Cursor)
 }
 
 func (c *List) Blur() tea.Cmd {
@@ -162,7 +164,7 @@ func (c *List) SetQuery(query string) tea.Cmd {
 	if c.focus == ListFocusItems {
 		c.query = query
 		if c.OnQueryChange != nil {
-			return tea.Tick(500*time.Millisecond, func(t time.Time) tea.Msg {
+			return delay(500*time.Millisecond, func(t time.Time) tea.Msg {
 				emptyText := ""
 				c.filter.EmptyText = emptyText
 				if query == c.input.Value() {
@@ -270,7 +272,7 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 
 	case tickMsg:
 		if msg.id == c.id {
-			nextTick := tea.Tick(time.Duration(c.autoRefreshSeconds)*time.Second, func(t time.Time) tea.Msg {
+			nextTick := delay(time.Duration(c.autoRefreshSeconds)*time.Second, func(t time.Time) tea.Msg {
 				return tickMsg{
 					id:   c.id,
 					time: t,
@@ -285,8 +287,8 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 		}
 		return c, nil
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case Keys.Matches(msg, KeyBack):
 			if c.statusBar.expanded {
 				c.focus = ListFocusItems
 				c.input.SetValue(c.query)
@@ -301,24 +303,24 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 			}
 
 			return c, PopPageCmd
-		case "ctrl+j":
+		case Keys.Matches(msg, KeyScrollDetailDown):
 			if !c.showDetail {
 				break
 			}
 
 			c.viewport.LineDown(1)
 			return c, nil
-		case "ctrl+p":
+		case Keys.Matches(msg, KeyToggleDetail):
 			c.SetShowDetail(!c.showDetail)
 			return c, nil
-		case "ctrl+k":
+		case Keys.Matches(msg, KeyScrollDetailUp):
 			if !c.showDetail {
 				break
 			}
 
 			c.viewport.LineUp(1)
 			return c, nil
-		case "tab":
+		case Keys.Matches(msg, KeyShowActions):
 			if c.statusBar.expanded {
 				break
 			}
@@ -336,15 +338,14 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 			c.statusBar.expanded = true
 			c.focus = ListFocusActions
 			return c, nil
-		case "right", "left":
-			if c.statusBar.expanded {
-				statusBar, cmd := c.statusBar.Update(msg)
-				c.statusBar = statusBar
-				return c, cmd
+		case Keys.Matches(msg, KeyNextAction), Keys.Matches(msg, KeyPreviousAction):
+			if !c.statusBar.expanded {
+				break
 			}
 
-			input, cmd := c.input.Update(msg)
-			c.input = input
+			// the keys must not move the cursor of the search input
+			statusBar, cmd := c.statusBar.Update(msg)
+			c.statusBar = statusBar
 			return c, cmd
 		}
 	case QueryChangeMsg:
@@ -399,7 +400,7 @@ func (c *List) Update(msg tea.Msg) (Page, tea.Cmd) {
 
 	if c.autoRefreshSeconds > 0 && !c.autoRefreshTriggered {
 		c.autoRefreshTriggered = true
-		cmd := tea.Tick(time.Duration(c.autoRefreshSeconds)*time.Second, func(t time.Time) tea.Msg {
+		cmd := delay(time.Duration(c.autoRefreshSeconds)*time.Second, func(t time.Time) tea.Msg {
 			return tickMsg{
 				id:   c.id,
 				time: t,
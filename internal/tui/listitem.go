@@ -19,8 +19,7 @@ func (i ListItem) ID() string {
 }
 
 func (i ListItem) FilterValue() string {
-	keywords := []string{i.Title, i.Subtitle}
-	return strings.Trim(strings.Join(keywords, " "), " ")
+	return sunbeam.ListItem(i).FilterValue()
 }
 
 func RenderItem(title string, subtitle string, accessories []string, width int, selected bool) string {
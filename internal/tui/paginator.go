@@ -1,7 +1,11 @@
 package tui
 
 import (
+	"os"
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/extensions"
 )
 
 func PopPageCmd() tea.Msg {
@@ -22,6 +26,19 @@ type PushPageMsg struct {
 	Page Page
 }
 
+// delayMsg asks the paginator for a timer, so that the harness can fire it without waiting
+type delayMsg struct {
+	d  time.Duration
+	fn func(time.Time) tea.Msg
+}
+
+// delay replaces tea.Tick in pages
+func delay(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return delayMsg{d: d, fn: fn}
+	}
+}
+
 type Page interface {
 	Init() tea.Cmd
 	Update(tea.Msg) (Page, tea.Cmd)
@@ -69,6 +86,22 @@ func (m *Paginator) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.hidden = true
 			return m, tea.Quit
 		}
+
+		if Keys.Matches(msg, KeyInspector) && extensions.Debug {
+			if _, ok := m.pages[len(m.pages)-1].(*Inspector); ok {
+				return m, m.Pop()
+			}
+
+			return m, m.Push(NewInspector())
+		}
+
+		if Keys.Matches(msg, KeyHelp) {
+			if _, ok := m.pages[len(m.pages)-1].(*Help); ok {
+				return m, m.Pop()
+			}
+
+			return m, m.Push(NewHelp())
+		}
 	case tea.WindowSizeMsg:
 		if msg.Height%2 == 0 {
 			m.SetSize(msg.Width, msg.Height-1)
@@ -76,6 +109,8 @@ func (m *Paginator) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.SetSize(msg.Width, msg.Height)
 		}
 		return m, nil
+	case delayMsg:
+		return m, tea.Tick(msg.d, msg.fn)
 	case PushPageMsg:
 		cmd := m.Push(msg.Page)
 		return m, cmd
@@ -126,6 +161,18 @@ func (m *Paginator) SetSize(width, height int) {
 	}
 }
 
+// Broadcast sends a message to every page of the stack, not only the current one
+func (m *Paginator) Broadcast(msg tea.Msg) tea.Cmd {
+	cmds := make([]tea.Cmd, 0, len(m.pages))
+	for i, page := range m.pages {
+		var cmd tea.Cmd
+		m.pages[i], cmd = page.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	return tea.Batch(cmds...)
+}
+
 func (m *Paginator) Push(page Page) tea.Cmd {
 	var cmd tea.Cmd
 	if len(m.pages) > 0 {
@@ -151,6 +198,10 @@ func (m *Paginator) Pop() tea.Cmd {
 }
 
 func Draw(page Page) error {
+	if KeyScript != "" {
+		return drawKeyScript(page, os.Stdout)
+	}
+
 	paginator := NewPaginator(page)
 	p := tea.NewProgram(paginator, tea.WithAltScreen())
 
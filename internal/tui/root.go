@@ -27,9 +27,10 @@ type RootList struct {
 	list          *List
 	form          *Form
 
-	config    config.Config
-	history   history.History
-	generator func() (config.Config, []sunbeam.ListItem, error)
+	config     config.Config
+	history    history.History
+	showDetail bool
+	generator  func() (config.Config, []sunbeam.ListItem, error)
 }
 
 type ReloadMsg struct{}
@@ -42,6 +43,23 @@ func NewRootList(title string, history history.History, generator func() (config
 	}
 }
 
+// SetShowDetail shows the detail of the selected item next to the list
+func (c *RootList) SetShowDetail(showDetail bool) {
+	c.showDetail = showDetail
+	if c.list != nil {
+		c.list.SetShowDetail(showDetail)
+	}
+}
+
+// Selection returns the selected item of the list
+func (c *RootList) Selection() (sunbeam.ListItem, bool) {
+	if c.list == nil {
+		return sunbeam.ListItem{}, false
+	}
+
+	return c.list.Selection()
+}
+
 func (c *RootList) Init() tea.Cmd {
 	termenv.DefaultOutput().SetWindowTitle(c.title)
 	return c.Reload()
@@ -62,6 +80,7 @@ func (c *RootList) Reload() tea.Cmd {
 	} else {
 		c.list = NewList(rootItems...)
 		c.list.SetEmptyText("No items")
+		c.list.SetShowDetail(c.showDetail)
 		c.list.SetSize(c.width, c.height)
 
 		return c.list.Init()
@@ -102,17 +121,17 @@ func (c *RootList) SetError(err error) tea.Cmd {
 func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case Keys.Matches(msg, KeyBack):
 			if c.form != nil {
 				c.form = nil
 				return c, c.list.Focus()
 			}
-		case "ctrl+s":
-			if c.form != nil {
+		case Keys.Matches(msg, KeyEdit):
+			if c.form != nil || !c.config.Loaded() {
 				break
 			}
-			editCmd := exec.Command("sunbeam", "edit", config.Path)
+			editCmd := exec.Command("sunbeam", "edit", c.config.Path())
 			return c, tea.ExecProcess(editCmd, func(err error) tea.Msg {
 				if err != nil {
 					return err
@@ -123,7 +142,7 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 
 				return ReloadMsg{}
 			})
-		case "ctrl+r":
+		case Keys.Matches(msg, KeyReload):
 			return c, tea.Batch(c.list.SetIsLoading(true), c.Reload())
 		}
 	case ReloadMsg:
@@ -141,11 +160,15 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 		switch msg.Type {
 		case sunbeam.ActionTypeRun:
 			extensionConfig := c.config.Extensions[msg.Run.Extension]
-			extension, err := extensions.LoadExtension(extensionConfig.Origin)
+			extension, err := extensions.LoadExtension(c.config, msg.Run.Extension)
 			if err != nil {
 				return c, c.SetError(fmt.Errorf("failed to load extension: %w", err))
 			}
 
+			if err := extension.CheckRequirements(); err != nil {
+				return c, c.SetError(err)
+			}
+
 			preferences := extensionConfig.Preferences
 			if preferences == nil {
 				preferences = make(map[string]any)
@@ -161,7 +184,8 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 
 			missingPreferences := FindMissingPreferences(extension.Manifest.Preferences, preferences)
 			for _, preference := range missingPreferences {
-				if preference.Optional {
+				// preferences are not recorded in fixtures, replayed runs do not need them
+				if preference.Optional || extensions.ReplayPath != "" {
 					continue
 				}
 
@@ -175,7 +199,8 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 					}
 
 					c.config.Extensions[msg.Run.Extension] = extensionConfig
-					if config.Path == "" {
+					// the preferences of configs built in memory only last until the launcher exits
+					if !c.config.Loaded() {
 						return msg
 					}
 
@@ -315,7 +340,7 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 				return c, c.SetError(fmt.Errorf("extension %s not found", msg.Config.Extension))
 			}
 
-			extension, err := extensions.LoadExtension(extensionConfig.Origin)
+			extension, err := extensions.LoadExtension(c.config, msg.Config.Extension)
 			if err != nil {
 				return c, c.SetError(fmt.Errorf("failed to load extension %s", msg.Config.Extension))
 			}
@@ -333,6 +358,10 @@ func (c *RootList) Update(msg tea.Msg) (Page, tea.Cmd) {
 				c.form = nil
 				extensionConfig.Preferences = values
 				c.config.Extensions[msg.Config.Extension] = extensionConfig
+				if !c.config.Loaded() {
+					return nil
+				}
+
 				if err := c.config.Save(); err != nil {
 					return err
 				}
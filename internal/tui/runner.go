@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"os/exec"
 
-	"github.com/acarl005/stripansi"
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/muesli/termenv"
@@ -22,6 +21,9 @@ type Runner struct {
 	form          *Form
 	width, height int
 	cancel        context.CancelFunc
+	// blurred runners are only reloaded once they are focused again
+	blurred bool
+	stale   bool
 
 	extension extensions.Extension
 	command   sunbeam.CommandSpec
@@ -79,11 +81,20 @@ func (c *Runner) Focus() tea.Cmd {
 		return nil
 	}
 	termenv.DefaultOutput().SetWindowTitle(fmt.Sprintf("%s - %s", c.command.Title, c.extension.Manifest.Title))
+	c.blurred = false
+	if c.stale {
+		c.stale = false
+		return tea.Batch(c.embed.Focus(), c.Reload())
+	}
+
 	return c.embed.Focus()
 }
 
 func (c *Runner) Blur() tea.Cmd {
-	c.cancel()
+	c.blurred = true
+	if c.cancel != nil {
+		c.cancel()
+	}
 	return nil
 }
 
@@ -101,8 +112,8 @@ func (c *Runner) SetSize(w int, h int) {
 func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc":
+		switch {
+		case Keys.Matches(msg, KeyBack):
 			if c.form != nil {
 				c.form = nil
 				return c, c.embed.Focus()
@@ -112,24 +123,24 @@ func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 				break
 			}
 			return c, PopPageCmd
-		case "ctrl+s":
+		case Keys.Matches(msg, KeyEdit):
 			editCmd := exec.Command("sunbeam", "edit", c.extension.Entrypoint)
 			return c, tea.ExecProcess(editCmd, func(err error) tea.Msg {
 				if err != nil {
 					return err
 				}
 
-				extension, err := extensions.LoadExtension(c.extension.Entrypoint)
+				manifest, err := c.extension.ExtractManifest()
 				if err != nil {
 					return err
 				}
-				c.extension = extension
+				c.extension.Manifest = manifest
 
 				return ReloadMsg{}
 			})
-		case "ctrl+r":
+		case Keys.Matches(msg, KeyReload):
 			return c, func() tea.Msg {
-				manifest, err := extensions.ExtractManifest(c.extension.Entrypoint)
+				manifest, err := c.extension.ExtractManifest()
 				if err != nil {
 					return err
 				}
@@ -139,6 +150,22 @@ func (c *Runner) Update(msg tea.Msg) (Page, tea.Cmd) {
 			}
 		}
 	case ReloadMsg:
+		return c, c.Reload()
+	case ExtensionChangedMsg:
+		c.extension.Manifest = msg.Manifest
+		command, ok := c.extension.Command(c.input.Command)
+		if !ok {
+			c.embed = NewErrorPage(fmt.Errorf("command %s not found", c.input.Command))
+			c.embed.SetSize(c.width, c.height)
+			return c, c.embed.Init()
+		}
+		c.command = command
+
+		if c.blurred {
+			c.stale = true
+			return c, nil
+		}
+
 		return c, c.Reload()
 	case Page:
 		c.embed = msg
@@ -342,20 +369,11 @@ func (c *Runner) Reload() tea.Cmd {
 		c.cancel = cancel
 		defer cancel()
 
-		cmd, err := c.extension.CmdContext(ctx, c.input)
-		if err != nil {
-			return err
-		}
-
-		output, err := cmd.Output()
+		output, err := c.extension.OutputContext(ctx, c.input)
 		if err != nil {
 			if errors.Is(ctx.Err(), context.Canceled) {
 				return nil
 			}
-			var exitErr *exec.ExitError
-			if errors.As(err, &exitErr) {
-				return fmt.Errorf("command failed: %s", stripansi.Strip(string(exitErr.Stderr)))
-			}
 
 			return err
 		}
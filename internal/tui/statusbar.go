@@ -78,13 +78,13 @@ func (c *StatusBar) FilterActions(query string) {
 func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "tab", "right":
+		switch {
+		case Keys.Matches(msg, KeyNextAction):
 			if !p.expanded {
 				break
 			}
 
-			if msg.String() == "tab" && len(p.filtered) == 0 {
+			if len(p.filtered) == 0 {
 				return p, nil
 			}
 
@@ -95,7 +95,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 			}
 
 			return p, nil
-		case "shift+tab", "left":
+		case Keys.Matches(msg, KeyPreviousAction):
 			if !p.expanded {
 				break
 			}
@@ -105,7 +105,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 			} else {
 				p.cursor = len(p.filtered) - 1
 			}
-		case "enter":
+		case Keys.Matches(msg, KeyRunAction):
 			if len(p.filtered) == 0 {
 				return p, nil
 			}
@@ -114,7 +114,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 			return p, func() tea.Msg {
 				return action
 			}
-		case "alt+enter":
+		case Keys.Matches(msg, KeySecondaryAction):
 			if p.cursor != 0 || len(p.actions) < 2 {
 				break
 			}
@@ -122,7 +122,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 			return p, func() tea.Msg {
 				return p.actions[1]
 			}
-		case "ctrl+d":
+		case Keys.Matches(msg, KeyClosePage):
 			if p.expanded {
 				break
 			}
@@ -130,7 +130,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 			return p, PopPageCmd
 		default:
 			for _, action := range p.actions {
-				if fmt.Sprintf("alt+%s", action.Key) == msg.String() {
+				if Keys.MatchesAction(msg, action.Key) {
 					return p, func() tea.Msg {
 						return action
 					}
@@ -144,7 +144,7 @@ func (p StatusBar) Update(msg tea.Msg) (StatusBar, tea.Cmd) {
 		}
 
 		p.notification = msg.Title
-		return p, tea.Tick(1*time.Second, func(t time.Time) tea.Msg {
+		return p, delay(1*time.Second, func(t time.Time) tea.Msg {
 			return HideNotificationMsg{}
 		})
 	case HideNotificationMsg:
@@ -194,11 +194,11 @@ func (c StatusBar) View() string {
 		for i, action := range c.filtered {
 			var subtitle string
 			if i == 0 {
-				subtitle = "enter"
+				subtitle = Keys.Help(KeyRunAction)
 			} else if i == 1 {
-				subtitle = "alt+enter"
-			} else if action.Key != "" {
-				subtitle = fmt.Sprintf("alt+%s", action.Key)
+				subtitle = Keys.Help(KeySecondaryAction)
+			} else {
+				subtitle = Keys.ActionHelp(action.Key)
 			}
 			accessories[i] = renderAction(ActionTitle(action), subtitle, i == c.cursor)
 		}
@@ -228,7 +228,7 @@ func (c StatusBar) View() string {
 		}
 
 	} else {
-		accessory = fmt.Sprintf("%s · Actions %s", renderAction(ActionTitle(c.filtered[0]), "enter", false), lipgloss.NewStyle().Faint(true).Render("tab"))
+		accessory = fmt.Sprintf("%s · Actions %s", renderAction(ActionTitle(c.filtered[0]), Keys.Help(KeyRunAction), false), lipgloss.NewStyle().Faint(true).Render(Keys.Help(KeyShowActions)))
 	}
 
 	var statusbar string
@@ -0,0 +1,139 @@
+package launcher_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/pkg/launcher"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+const helloScript = `#!/bin/sh
+echo '{"title": "Hello", "commands": [{"name": "greet", "title": "Greet", "mode": "detail"}]}'
+`
+
+// TestMain keeps the downloads, the index and the config of the examples out of the user directories
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "launcher")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Setenv("XDG_CACHE_HOME", dir)
+	extensions.IndexPath = filepath.Join(dir, "sunbeam", "extensions", "index.json")
+	config.Path = filepath.Join(dir, "config", "sunbeam.json")
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func ExampleLauncher_AddExtension() {
+	stateDir, err := os.MkdirTemp("", "launcher")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	l := launcher.New("Deploy", stateDir)
+	l.AddItems(launcher.Item{ID: "staging", Title: "Deploy to Staging"})
+
+	// local origins are not resolved against the working directory
+	if err := l.AddExtension("hello", "hello.sh", nil); err != nil {
+		fmt.Println(err)
+	}
+
+	for _, item := range l.RootItems() {
+		fmt.Println(item.Title)
+	}
+	// Output:
+	// local origin hello.sh must be an absolute path
+	// Deploy to Staging
+}
+
+func ExampleLoad() {
+	dir, err := os.MkdirTemp("", "launcher")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "hello.sh"), []byte(helloScript), 0755); err != nil {
+		panic(err)
+	}
+
+	// relative origins are resolved from the directory of the config file
+	configPath := filepath.Join(dir, "sunbeam.json")
+	if err := os.WriteFile(configPath, []byte(`{"extensions": {"hello": {"origin": "hello.sh"}}}`), 0644); err != nil {
+		panic(err)
+	}
+
+	l, err := launcher.Load("Launcher", configPath)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, item := range l.RootItems() {
+		fmt.Printf("%s - %s\n", item.Title, item.Subtitle)
+	}
+	// Output:
+	// Greet - Hello
+}
+
+func ExampleLauncher_AddExtensionManifest() {
+	dir, err := os.MkdirTemp("", "launcher")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the entrypoint fails when it is run without a command, the manifest is never extracted from it
+	entrypoint := filepath.Join(dir, "deploy.sh")
+	if err := os.WriteFile(entrypoint, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		panic(err)
+	}
+
+	l := launcher.New("Deploy", filepath.Join(dir, "state"))
+	if err := l.AddExtensionManifest("deploy", entrypoint, sunbeam.Manifest{
+		Title: "Deploy",
+		Commands: []sunbeam.CommandSpec{
+			{Name: "logs", Title: "Show Logs", Mode: sunbeam.CommandModeDetail},
+		},
+	}, nil); err != nil {
+		panic(err)
+	}
+
+	for _, item := range l.RootItems() {
+		fmt.Printf("%s - %s\n", item.Title, item.Subtitle)
+	}
+	// Output:
+	// Show Logs - Deploy
+}
+
+func ExampleLauncher_RunKeyScript() {
+	stateDir, err := os.MkdirTemp("", "launcher")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(stateDir)
+
+	l := launcher.New("Deploy", stateDir)
+	l.AddItems(
+		launcher.Item{ID: "staging", Title: "Deploy to Staging"},
+		launcher.Item{ID: "production", Title: "Deploy to Production"},
+	)
+
+	result, err := l.RunKeyScript("type production, enter")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(result.Item.ID, "-", result.Action.Title)
+	// Output:
+	// production - Select
+}
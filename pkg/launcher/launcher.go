@@ -0,0 +1,261 @@
+// Package launcher hosts the sunbeam launcher in go programs.
+//
+// A launcher shows the items of the host program next to the commands of its extensions.
+// Extensions are run by the launcher, while the selection of a host item is returned to the program:
+//
+//	l := launcher.New("Deploy", stateDir)
+//	l.AddItems(
+//		launcher.Item{ID: "staging", Title: "Deploy to Staging"},
+//		launcher.Item{ID: "production", Title: "Deploy to Production"},
+//	)
+//
+//	if err := l.AddExtension("github", "/path/to/github.sh", nil); err != nil {
+//		return err
+//	}
+//
+//	result, err := l.Run()
+//	if err != nil {
+//		return err
+//	}
+//
+//	if result.Item != nil {
+//		fmt.Println("selected", result.Item.ID)
+//	}
+package launcher
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/history"
+	"github.com/pomdtr/sunbeam/internal/tui"
+	"github.com/pomdtr/sunbeam/pkg/sunbeam"
+)
+
+// Item is a root item of the host program
+type Item struct {
+	ID          string
+	Title       string
+	Subtitle    string
+	Accessories []string
+	// Actions are run by the launcher, like the actions of extensions.
+	// An item without actions exits the launcher when it is selected.
+	Actions []sunbeam.Action
+}
+
+// Result is the last action the user selected on a host item
+type Result struct {
+	// Item is nil if no host item was selected
+	Item   *Item
+	Action *sunbeam.Action
+}
+
+type Launcher struct {
+	title       string
+	config      config.Config
+	keymap      tui.Keymap
+	items       []Item
+	historyPath string
+}
+
+// New returns a launcher without extensions, they are added with AddExtension.
+// The lockfile and the manifest index of the extensions are kept in the state directory, the files of the sunbeam cli are left alone.
+func New(title string, stateDir string) *Launcher {
+	return &Launcher{
+		title:  title,
+		config: config.New(stateDir),
+		keymap: tui.Keys,
+	}
+}
+
+// Load returns a launcher with the oneliners, the extensions and the keybindings of a sunbeam config file.
+// Preferences entered in the launcher are saved to the config file.
+func Load(title string, configPath string) (*Launcher, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keymap, err := tui.LoadKeymap(cfg.Keybindings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return &Launcher{
+		title:  title,
+		config: cfg,
+		keymap: keymap,
+	}, nil
+}
+
+// AddExtension installs an extension from its origin, as `sunbeam extension install` does, without saving it to a config file.
+// Local origins must be absolute paths.
+func (l *Launcher) AddExtension(alias string, origin string, preferences map[string]any) error {
+	if _, ok := l.config.Extensions[alias]; ok {
+		return fmt.Errorf("extension %s already exists", alias)
+	}
+
+	if !extensions.IsRemote(origin) && !filepath.IsAbs(origin) {
+		return fmt.Errorf("local origin %s must be an absolute path", origin)
+	}
+
+	return l.addExtension(alias, config.ExtensionConfig{
+		Origin:      origin,
+		Preferences: preferences,
+	})
+}
+
+// AddExtensionManifest adds an extension shipped with the program, its entrypoint is not run to extract the manifest.
+// The entrypoint must be an absolute path.
+func (l *Launcher) AddExtensionManifest(alias string, entrypoint string, manifest sunbeam.Manifest, preferences map[string]any) error {
+	if _, ok := l.config.Extensions[alias]; ok {
+		return fmt.Errorf("extension %s already exists", alias)
+	}
+
+	if !filepath.IsAbs(entrypoint) {
+		return fmt.Errorf("entrypoint %s must be an absolute path", entrypoint)
+	}
+
+	return l.addExtension(alias, config.ExtensionConfig{
+		Origin:      entrypoint,
+		Preferences: preferences,
+		Manifest:    &manifest,
+	})
+}
+
+func (l *Launcher) addExtension(alias string, extensionConfig config.ExtensionConfig) error {
+	l.config.Extensions[alias] = extensionConfig
+	if _, err := extensions.LoadExtension(l.config, alias); err != nil {
+		delete(l.config.Extensions, alias)
+		return fmt.Errorf("failed to load extension %s: %w", alias, err)
+	}
+
+	return nil
+}
+
+// AddItems adds root items, they are shown before the commands of the extensions
+func (l *Launcher) AddItems(items ...Item) {
+	l.items = append(l.items, items...)
+}
+
+// SetHistory persists the usage of the root items to a file, they are sorted by last use.
+// The history is only kept in memory otherwise.
+func (l *Launcher) SetHistory(path string) {
+	l.historyPath = path
+}
+
+// Run shows the launcher until the user exits it, or selects a host item without actions.
+// The keybindings of the launcher are only active while it runs.
+func (l *Launcher) Run() (Result, error) {
+	return l.run(tui.Draw)
+}
+
+// RunKeyScript drives the launcher with a key script instead of the terminal, as the --key-script flag of the cli does.
+func (l *Launcher) RunKeyScript(script string) (Result, error) {
+	return l.run(func(page tui.Page) error {
+		_, err := tui.RunKeyScript(page, script)
+		return err
+	})
+}
+
+func (l *Launcher) run(draw func(tui.Page) error) (Result, error) {
+	h := history.New()
+	if l.historyPath != "" {
+		loaded, err := history.Load(l.historyPath)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to load history: %w", err)
+		}
+		h = loaded
+	}
+
+	keys := tui.Keys
+	tui.Keys = l.keymap
+	defer func() {
+		tui.Keys = keys
+	}()
+
+	items := make(map[string]Item)
+	root := &rootPage{
+		items: items,
+	}
+	root.RootList = tui.NewRootList(l.title, h, func() (config.Config, []sunbeam.ListItem, error) {
+		for _, item := range l.items {
+			items[hostListItem(item).Id] = item
+		}
+
+		return l.config, l.RootItems(), nil
+	})
+
+	if err := draw(root); err != nil {
+		return Result{}, err
+	}
+
+	return root.result, nil
+}
+
+// RootItems returns the items shown by the launcher: the host items, the oneliners, then the commands of the extensions.
+// Extensions failing to load are skipped.
+func (l *Launcher) RootItems() []sunbeam.ListItem {
+	var listItems []sunbeam.ListItem
+	for _, item := range l.items {
+		listItems = append(listItems, hostListItem(item))
+	}
+
+	listItems = append(listItems, tui.OnelinerListItems(l.config.Oneliners)...)
+	extensionMap, _ := extensions.LoadExtensions(l.config)
+	aliases := l.config.Aliases()
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		extension, ok := extensionMap[alias]
+		if !ok {
+			continue
+		}
+		listItems = append(listItems, tui.ExtensionListItems(alias, extension, l.config.Extensions[alias])...)
+	}
+
+	return listItems
+}
+
+func hostListItem(item Item) sunbeam.ListItem {
+	actions := item.Actions
+	if len(actions) == 0 {
+		actions = []sunbeam.Action{
+			{
+				Title: "Select",
+				Type:  sunbeam.ActionTypeExit,
+			},
+		}
+	}
+
+	return sunbeam.ListItem{
+		Id:          fmt.Sprintf("host - %s", item.ID),
+		Title:       item.Title,
+		Subtitle:    item.Subtitle,
+		Accessories: item.Accessories,
+		Actions:     actions,
+	}
+}
+
+// rootPage records the actions selected on host items, before the root list runs them
+type rootPage struct {
+	*tui.RootList
+	items  map[string]Item
+	result Result
+}
+
+func (p *rootPage) Update(msg tea.Msg) (tui.Page, tea.Cmd) {
+	if action, ok := msg.(sunbeam.Action); ok {
+		if selection, ok := p.Selection(); ok {
+			if item, ok := p.items[selection.Id]; ok {
+				p.result = Result{Item: &item, Action: &action}
+			}
+		}
+	}
+
+	_, cmd := p.RootList.Update(msg)
+	return p, cmd
+}
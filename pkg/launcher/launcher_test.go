@@ -0,0 +1,74 @@
+package launcher_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pomdtr/sunbeam/internal/config"
+	"github.com/pomdtr/sunbeam/internal/extensions"
+	"github.com/pomdtr/sunbeam/internal/tui"
+	"github.com/pomdtr/sunbeam/pkg/launcher"
+)
+
+func TestNewStateDir(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(helloScript))
+	}))
+	t.Cleanup(ts.Close)
+
+	stateDir := filepath.Join(t.TempDir(), "state")
+	l := launcher.New("Launcher", stateDir)
+	if err := l.AddExtension("hello", ts.URL+"/hello.sh", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"sunbeam.lock", "index.json"} {
+		if _, err := os.Stat(filepath.Join(stateDir, name)); err != nil {
+			t.Errorf("expected %s to be kept in the state directory: %v", name, err)
+		}
+	}
+
+	for _, path := range []string{extensions.IndexPath, filepath.Join(filepath.Dir(config.Path), "sunbeam.lock")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("expected %s not to be written", path)
+		}
+	}
+}
+
+func TestLoadKeymap(t *testing.T) {
+	keys := tui.Keys
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "sunbeam.json")
+	if err := os.WriteFile(configPath, []byte(`{"keybindings": {"reload": ["ctrl+g"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := launcher.Load("Launcher", configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(tui.Keys, keys) {
+		t.Errorf("expected the keybindings not to be active before the launcher runs")
+	}
+
+	if _, err := l.RunKeyScript("down"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(tui.Keys, keys) {
+		t.Errorf("expected the keybindings to be restored once the launcher exits")
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"keybindings": {"unknown": ["ctrl+n"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := launcher.Load("Launcher", configPath); err == nil {
+		t.Errorf("expected invalid keybindings to be rejected")
+	}
+}
@@ -62,6 +62,54 @@ func (a *Action) UnmarshalJSON(bts []byte) error {
 	return nil
 }
 
+// MarshalJSON flattens the props of the action next to its title, key and type, as UnmarshalJSON expects them
+func (a Action) MarshalJSON() ([]byte, error) {
+	var props any
+	switch {
+	case a.Type == ActionTypeRun && a.Run != nil:
+		props = a.Run
+	case a.Type == ActionTypeOpen && a.Open != nil:
+		props = a.Open
+	case a.Type == ActionTypeCopy && a.Copy != nil:
+		props = a.Copy
+	case a.Type == ActionTypeEdit && a.Edit != nil:
+		props = a.Edit
+	case a.Type == ActionTypeExec && a.Exec != nil:
+		props = a.Exec
+	case a.Type == ActionTypeReload && a.Reload != nil:
+		props = a.Reload
+	case a.Type == ActionTypeConfig && a.Config != nil:
+		props = a.Config
+	}
+
+	// raw messages keep the params of the action as they are
+	fields := make(map[string]json.RawMessage)
+	if props != nil {
+		bts, err := json.Marshal(props)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(bts, &fields); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, value := range map[string]string{"title": a.Title, "key": a.Key, "type": string(a.Type)} {
+		if value == "" {
+			continue
+		}
+
+		bts, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = bts
+	}
+
+	return json.Marshal(fields)
+}
+
 type ConfigAction struct {
 	Extension string `json:"extension,omitempty"`
 }
@@ -0,0 +1,45 @@
+package sunbeam
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestActionRoundTrip(t *testing.T) {
+	for _, action := range []Action{
+		{Title: "Run", Key: "r", Type: ActionTypeRun, Run: &RunAction{Command: "hi", Params: map[string]any{"name": "foo", "count": float64(2)}, Reload: true}},
+		{Title: "Open", Type: ActionTypeOpen, Open: &OpenAction{Url: "https://example.com"}},
+		{Title: "Copy", Type: ActionTypeCopy, Copy: &CopyAction{Text: "foo", Exit: true}},
+		{Title: "Edit", Type: ActionTypeEdit, Edit: &EditAction{Path: "/tmp/foo", Reload: true}},
+		{Title: "Exec", Type: ActionTypeExec, Exec: &ExecAction{Command: "ls", Dir: "/tmp", Interactive: true}},
+		{Title: "Reload", Type: ActionTypeReload, Reload: &ReloadAction{Params: map[string]any{"page": float64(2)}}},
+		{Title: "Config", Type: ActionTypeConfig, Config: &ConfigAction{Extension: "github"}},
+		{Title: "Exit", Type: ActionTypeExit},
+	} {
+		bts, err := json.Marshal(action)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded Action
+		if err := json.Unmarshal(bts, &decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(action, decoded) {
+			t.Errorf("%s action changed after a round trip: %s", action.Type, bts)
+		}
+	}
+}
+
+func TestActionMarshalFlattensProps(t *testing.T) {
+	bts, err := json.Marshal(Action{Title: "Copy", Type: ActionTypeCopy, Copy: &CopyAction{Text: "foo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `{"text":"foo","title":"Copy","type":"copy"}`; string(bts) != expected {
+		t.Errorf("expected %s, got %s", expected, bts)
+	}
+}
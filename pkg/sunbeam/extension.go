@@ -0,0 +1,154 @@
+package sunbeam
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pomdtr/sunbeam/internal/schemas"
+)
+
+// ListHandler handles the payload of a search or filter command
+type ListHandler func(payload Payload) (List, error)
+
+// DetailHandler handles the payload of a detail command
+type DetailHandler func(payload Payload) (Detail, error)
+
+// Handler handles the payload of a silent or tty command, which has no view
+type Handler func(payload Payload) error
+
+// Extension implements the sunbeam protocol: the manifest is printed when the entrypoint is run without arguments,
+// otherwise the payload is decoded from the first argument, and passed to the handler of its command.
+type Extension struct {
+	Manifest Manifest
+	handlers map[string]any
+}
+
+func NewExtension(manifest Manifest) *Extension {
+	return &Extension{
+		Manifest: manifest,
+		handlers: make(map[string]any),
+	}
+}
+
+func (e *Extension) HandleList(command string, handler ListHandler) {
+	e.handlers[command] = handler
+}
+
+func (e *Extension) HandleDetail(command string, handler DetailHandler) {
+	e.handlers[command] = handler
+}
+
+func (e *Extension) Handle(command string, handler Handler) {
+	e.handlers[command] = handler
+}
+
+// Main runs the extension with the arguments of the process, errors are printed to stderr and exit the process with status 1
+func (e *Extension) Main() {
+	if err := e.Run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Run prints the manifest if there are no arguments, or runs the command of the payload.
+// The manifest and the outputs are validated against the sunbeam schemas before being written.
+func (e *Extension) Run(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		for _, command := range e.Manifest.Commands {
+			if err := e.checkHandler(command); err != nil {
+				return err
+			}
+		}
+
+		return writeJSON(w, e.Manifest, schemas.ValidateManifest)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal([]byte(args[0]), &payload); err != nil {
+		return fmt.Errorf("failed to decode payload: %w", err)
+	}
+
+	command, ok := e.command(payload.Command)
+	if !ok {
+		return fmt.Errorf("command %s not found", payload.Command)
+	}
+
+	if err := e.checkHandler(command); err != nil {
+		return err
+	}
+
+	switch handler := e.handlers[command.Name].(type) {
+	case ListHandler:
+		list, err := handler(payload)
+		if err != nil {
+			return err
+		}
+
+		return writeJSON(w, list, schemas.ValidateList)
+	case DetailHandler:
+		detail, err := handler(payload)
+		if err != nil {
+			return err
+		}
+
+		return writeJSON(w, detail, schemas.ValidateDetail)
+	case Handler:
+		return handler(payload)
+	default:
+		return fmt.Errorf("invalid handler for command %s", command.Name)
+	}
+}
+
+func (e *Extension) command(name string) (CommandSpec, bool) {
+	for _, command := range e.Manifest.Commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+
+	return CommandSpec{}, false
+}
+
+// checkHandler reports commands without handler, or with a handler not matching their mode
+func (e *Extension) checkHandler(command CommandSpec) error {
+	handler, ok := e.handlers[command.Name]
+	if !ok {
+		return fmt.Errorf("no handler registered for command %s", command.Name)
+	}
+
+	switch handler.(type) {
+	case ListHandler:
+		if command.Mode == CommandModeSearch || command.Mode == CommandModeFilter {
+			return nil
+		}
+	case DetailHandler:
+		if command.Mode == CommandModeDetail {
+			return nil
+		}
+	case Handler:
+		if command.Mode == CommandModeSilent || command.Mode == CommandModeTTY {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("the handler of command %s does not match its mode %s", command.Name, command.Mode)
+}
+
+func writeJSON(w io.Writer, v any, validate func([]byte) error) error {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := validate(bts); err != nil {
+		return fmt.Errorf("invalid output: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(w, string(bts)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package sunbeam
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func newTestExtension() *Extension {
+	extension := NewExtension(Manifest{
+		Title: "Test",
+		Commands: []CommandSpec{
+			{Name: "list", Title: "List", Mode: CommandModeFilter},
+			{Name: "detail", Title: "Detail", Mode: CommandModeDetail},
+			{Name: "silent", Title: "Silent", Mode: CommandModeSilent},
+		},
+	})
+
+	extension.HandleList("list", func(payload Payload) (List, error) {
+		return List{Items: []ListItem{{Title: payload.Query}}}, nil
+	})
+
+	extension.HandleDetail("detail", func(payload Payload) (Detail, error) {
+		return Detail{Text: payload.Params["name"].(string)}, nil
+	})
+
+	extension.Handle("silent", func(payload Payload) error {
+		return nil
+	})
+
+	return extension
+}
+
+func TestRunManifest(t *testing.T) {
+	var out bytes.Buffer
+	if err := newTestExtension().Run(nil, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(out.Bytes(), &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.Title != "Test" || len(manifest.Commands) != 3 {
+		t.Errorf("unexpected manifest: %s", out.String())
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	for _, tc := range []struct {
+		payload  string
+		expected string
+	}{
+		{`{"command": "list", "query": "foo"}`, `{"items":[{"title":"foo"}]}`},
+		{`{"command": "detail", "params": {"name": "bar"}}`, `{"text":"bar"}`},
+		{`{"command": "silent"}`, ``},
+	} {
+		var out bytes.Buffer
+		if err := newTestExtension().Run([]string{tc.payload}, &out); err != nil {
+			t.Fatalf("failed to run %s: %v", tc.payload, err)
+		}
+
+		if got := strings.TrimSpace(out.String()); got != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, got)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	mismatch := NewExtension(Manifest{
+		Title:    "Test",
+		Commands: []CommandSpec{{Name: "detail", Title: "Detail", Mode: CommandModeDetail}},
+	})
+	mismatch.HandleList("detail", func(payload Payload) (List, error) {
+		return List{}, nil
+	})
+
+	missing := NewExtension(Manifest{
+		Title:    "Test",
+		Commands: []CommandSpec{{Name: "detail", Title: "Detail", Mode: CommandModeDetail}},
+	})
+
+	invalid := NewExtension(Manifest{
+		Title:    "Test",
+		Commands: []CommandSpec{{Name: "detail", Title: "Detail", Mode: CommandModeDetail}},
+	})
+	// the schema does not allow a detail with both a text and a markdown
+	invalid.HandleDetail("detail", func(payload Payload) (Detail, error) {
+		return Detail{Text: "text", Markdown: "markdown"}, nil
+	})
+
+	for _, tc := range []struct {
+		name      string
+		extension *Extension
+		args      []string
+		err       string
+	}{
+		{"mode mismatch in manifest", mismatch, nil, "does not match its mode detail"},
+		{"mode mismatch in run", mismatch, []string{`{"command": "detail"}`}, "does not match its mode detail"},
+		{"missing handler", missing, nil, "no handler registered for command detail"},
+		{"unknown command", newTestExtension(), []string{`{"command": "unknown"}`}, "command unknown not found"},
+		{"invalid payload", newTestExtension(), []string{`{`}, "failed to decode payload"},
+		{"invalid output", invalid, []string{`{"command": "detail"}`}, "invalid output"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := tc.extension.Run(tc.args, &out)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+
+			if out.Len() > 0 {
+				t.Errorf("expected nothing to be written, got %s", out.String())
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package sunbeam
 
 type Manifest struct {
-	Title       string        `json:"title"`
-	Description string        `json:"description,omitempty"`
-	Preferences []Input       `json:"preferences,omitempty"`
-	Commands    []CommandSpec `json:"commands"`
+	Title        string        `json:"title"`
+	Description  string        `json:"description,omitempty"`
+	Platforms    []Platform    `json:"platforms,omitempty"`
+	Requirements []Requirement `json:"requirements,omitempty"`
+	Preferences  []Input       `json:"preferences,omitempty"`
+	Commands     []CommandSpec `json:"commands"`
 }
 
 type CommandSpec struct {
@@ -15,11 +17,15 @@ type CommandSpec struct {
 	Mode   CommandMode `json:"mode,omitempty"`
 }
 
-type Platfom string
+type Platform string
+
+// Deprecated: use Platform
+type Platfom = Platform
 
 const (
-	PlatformLinux Platfom = "linux"
-	PlatformMac   Platfom = "macos"
+	PlatformLinux   Platform = "linux"
+	PlatformMac     Platform = "macos"
+	PlatformWindows Platform = "windows"
 )
 
 type Requirement struct {
@@ -1,5 +1,10 @@
 package sunbeam
 
+import (
+	"encoding/json"
+	"strings"
+)
+
 type List struct {
 	Items              []ListItem `json:"items,omitempty"`
 	EmptyText          string     `json:"emptyText,omitempty"`
@@ -17,6 +22,29 @@ type ListItem struct {
 	Actions     []Action       `json:"actions,omitempty"`
 }
 
+// FilterValue is matched against the query of filter lists
+func (i ListItem) FilterValue() string {
+	keywords := []string{i.Title, i.Subtitle}
+	return strings.Trim(strings.Join(keywords, " "), " ")
+}
+
+// MarshalJSON omits the detail of the item when it is empty, since the schema requires its text or markdown
+func (i ListItem) MarshalJSON() ([]byte, error) {
+	type listItem ListItem
+	item := struct {
+		listItem
+		Detail *ListItemDetail `json:"detail,omitempty"`
+	}{
+		listItem: listItem(i),
+	}
+
+	if i.Detail != (ListItemDetail{}) {
+		item.Detail = &i.Detail
+	}
+
+	return json.Marshal(item)
+}
+
 type ListItemDetail struct {
 	Markdown string `json:"markdown,omitempty"`
 	Text     string `json:"text,omitempty"`
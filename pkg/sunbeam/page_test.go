@@ -0,0 +1,41 @@
+package sunbeam
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestListItemRoundTrip(t *testing.T) {
+	for _, item := range []ListItem{
+		{Title: "No Detail", Accessories: []string{"a"}},
+		{Id: "id", Title: "Detail", Subtitle: "subtitle", Detail: ListItemDetail{Markdown: "# Hi"}, Actions: []Action{
+			{Title: "Copy", Type: ActionTypeCopy, Copy: &CopyAction{Text: "foo"}},
+		}},
+	} {
+		bts, err := json.Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded ListItem
+		if err := json.Unmarshal(bts, &decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(item, decoded) {
+			t.Errorf("item changed after a round trip: %s", bts)
+		}
+	}
+}
+
+func TestListItemMarshalOmitsEmptyDetail(t *testing.T) {
+	bts, err := json.Marshal(ListItem{Title: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := `{"title":"foo"}`; string(bts) != expected {
+		t.Errorf("expected %s, got %s", expected, bts)
+	}
+}
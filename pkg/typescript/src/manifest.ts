@@ -1,10 +1,19 @@
 export type Manifest = {
   title: string;
   description: string;
+  platforms?: readonly Platform[];
+  requirements?: readonly Requirement[];
   preferences?: readonly Input[];
   commands: readonly Command[];
 };
 
+export type Platform = "linux" | "macos" | "windows";
+
+export type Requirement = {
+  name: string;
+  link?: string;
+};
+
 export type Command = {
   name: string;
   hidden?: boolean;
@@ -3,6 +3,7 @@
 import * as path from "https://deno.land/std@0.208.0/path/mod.ts";
 const dirname = new URL(".", import.meta.url).pathname;
 const rows = [];
+const catalog = [];
 
 rows.push(
   "---",
@@ -29,6 +30,12 @@ for (const entry of entries) {
   } catch (_) {
     console.error(`Failed to parse manifest for ${entry.name}`);
   }
+  catalog.push({
+    name: path.parse(entry.name).name,
+    origin: `https://raw.githubusercontent.com/pomdtr/sunbeam/main/extensions/${entry.name}`,
+    manifest,
+  });
+
   rows.push(
     "",
     `## [${manifest.title}](https://github.com/pomdtr/sunbeam/tree/main/extensions/${entry.name})`,
@@ -64,3 +71,9 @@ Deno.writeTextFileSync(
   path.join(dirname, "..", "www", "website", "catalog", "index.md"),
   rows.join("\n"),
 );
+
+// the catalog index read by sunbeam extension search and browse
+Deno.writeTextFileSync(
+  path.join(dirname, "..", "www", "public", "catalog.json"),
+  JSON.stringify({ extensions: catalog }, null, 2),
+);